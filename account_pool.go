@@ -0,0 +1,224 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+// the most operations the network accepts in one transaction
+const maxOperationsPerTx = 100
+
+// AccountPool is a set of funded accounts created from the root TargetNetworkSecretKey account.
+// Each scenario leases one to sign with, so scenarios running at once never contend for the
+// sequence number of a shared source account.
+type AccountPool struct {
+	accounts chan *keypair.Full
+}
+
+// creates size new accounts, each funded with startingBalance XLM from the config's TargetNetworkSecretKey
+// account, the create account operations are batched up to maxOperationsPerTx per transaction
+func NewAccountPool(e2eConfig *E2EConfig, size int, startingBalance string) (*AccountPool, error) {
+	rootKp, err := keypair.ParseFull(e2eConfig.TargetNetworkSecretKey)
+	if err != nil {
+		return nil, RedactError(fmt.Errorf("invalid TargetNetworkSecretKey for account pool, %v", err))
+	}
+
+	rootState, err := QueryAccount(e2eConfig, rootKp.Address())
+	if err != nil {
+		return nil, fmt.Errorf("unable to query root account %v for account pool, %v", rootKp.Address(), err)
+	}
+	rootAccount := txnbuild.NewSimpleAccount(rootKp.Address(), rootState.Sequence)
+
+	accounts := make([]*keypair.Full, 0, size)
+	for len(accounts) < size {
+		kp, err := keypair.Random()
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate key pair for account pool, %v", err)
+		}
+		accounts = append(accounts, kp)
+	}
+
+	for start := 0; start < len(accounts); start += maxOperationsPerTx {
+		batch := accounts[start:min(start+maxOperationsPerTx, len(accounts))]
+
+		// NewTransaction increments the root account sequence for each batch
+		tx, err := createAccountsTx(&rootAccount, batch, startingBalance)
+		if err != nil {
+			return nil, err
+		}
+
+		if tx, err = tx.Sign(e2eConfig.TargetNetworkPassPhrase, rootKp); err != nil {
+			return nil, fmt.Errorf("signing transaction to create pool accounts had error %v", err)
+		}
+
+		if _, err = TxSub(e2eConfig, tx); err != nil {
+			return nil, fmt.Errorf("not able to submit transaction to create pool accounts %v to %v, %w", start+1, start+len(batch), err)
+		}
+	}
+
+	if e2eConfig.VerboseOutput {
+		fmt.Printf("created and funded %v pool accounts with %v XLM each\n", size, startingBalance)
+	}
+
+	return newAccountPool(accounts), nil
+}
+
+func newAccountPool(accounts []*keypair.Full) *AccountPool {
+	pool := &AccountPool{accounts: make(chan *keypair.Full, len(accounts))}
+	for _, kp := range accounts {
+		pool.accounts <- kp
+	}
+	return pool
+}
+
+func createAccountsTx(sourceAccount *txnbuild.SimpleAccount, accounts []*keypair.Full, startingBalance string) (*txnbuild.Transaction, error) {
+	ops := make([]txnbuild.Operation, 0, len(accounts))
+	for _, kp := range accounts {
+		ops = append(ops, &txnbuild.CreateAccount{
+			Destination: kp.Address(),
+			Amount:      startingBalance,
+		})
+	}
+
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount:        sourceAccount,
+		IncrementSequenceNum: true,
+		Operations:           ops,
+		BaseFee:              txnbuild.MinBaseFee,
+		Preconditions: txnbuild.Preconditions{
+			TimeBounds: txnbuild.NewInfiniteTimeout(),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("building transaction to create pool accounts had error %v", err)
+	}
+
+	return tx, nil
+}
+
+// number of accounts in the pool, leased or not
+func (p *AccountPool) Size() int {
+	return cap(p.accounts)
+}
+
+// takes an account out of the pool, waiting until one is returned if all are leased
+func (p *AccountPool) Lease(ctx context.Context) (*keypair.Full, error) {
+	select {
+	case kp := <-p.accounts:
+		return kp, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("no pool account was returned to lease, %w", ctx.Err())
+	}
+}
+
+// puts a leased account back in the pool for another scenario to use
+func (p *AccountPool) Return(kp *keypair.Full) {
+	p.accounts <- kp
+}
+
+// merges the pool accounts back into the config's TargetNetworkSecretKey account, returning their balances to it,
+// the account merge operations are batched up to maxOperationsPerTx per transaction.
+// accounts that can't be merged are reported in the error after the rest are merged,
+// accounts still leased are not merged and the pool can't be used afterwards.
+func (p *AccountPool) Close(e2eConfig *E2EConfig) error {
+	accounts := []*keypair.Full{}
+	for len(p.accounts) > 0 {
+		accounts = append(accounts, <-p.accounts)
+	}
+
+	rootKp, err := keypair.ParseFull(e2eConfig.TargetNetworkSecretKey)
+	if err != nil {
+		return RedactError(fmt.Errorf("invalid TargetNetworkSecretKey for account pool, %v", err))
+	}
+
+	failed := []string{}
+	for start := 0; start < len(accounts); start += maxOperationsPerTx {
+		batch := accounts[start:min(start+maxOperationsPerTx, len(accounts))]
+
+		err := mergeAccounts(e2eConfig, rootKp, batch)
+		if err == nil {
+			continue
+		}
+		if len(batch) == 1 {
+			failed = append(failed, fmt.Sprintf("%v %v", batch[0].Address(), err))
+			continue
+		}
+
+		// one account that can't be merged, i.e. a scenario left trustlines, offers or data entries on it,
+		// fails the whole batch, so merge the accounts of the batch one at a time
+		for _, kp := range batch {
+			if err := mergeAccounts(e2eConfig, rootKp, []*keypair.Full{kp}); err != nil {
+				failed = append(failed, fmt.Sprintf("%v %v", kp.Address(), err))
+			}
+		}
+	}
+
+	if e2eConfig.VerboseOutput {
+		fmt.Printf("merged %v pool accounts back into the root account\n", len(accounts)-len(failed))
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("not able to merge %v pool accounts back into the root account, %v", len(failed), strings.Join(failed, ", "))
+	}
+
+	if leased := p.Size() - len(accounts); leased > 0 {
+		return fmt.Errorf("%v pool accounts were still leased and not merged", leased)
+	}
+
+	return nil
+}
+
+// merges the accounts into the root account in one transaction, the root account sequence is queried
+// for each transaction as one that failed may or may not have used its sequence number
+func mergeAccounts(e2eConfig *E2EConfig, rootKp *keypair.Full, accounts []*keypair.Full) error {
+	rootState, err := QueryAccount(e2eConfig, rootKp.Address())
+	if err != nil {
+		return fmt.Errorf("unable to query root account %v for account pool, %v", rootKp.Address(), err)
+	}
+	rootAccount := txnbuild.NewSimpleAccount(rootKp.Address(), rootState.Sequence)
+
+	tx, err := mergeAccountsTx(&rootAccount, accounts)
+	if err != nil {
+		return err
+	}
+
+	// each merged account authorizes its own merge, the root account pays the fee
+	if tx, err = tx.Sign(e2eConfig.TargetNetworkPassPhrase, append([]*keypair.Full{rootKp}, accounts...)...); err != nil {
+		return fmt.Errorf("signing transaction to merge pool accounts had error %v", err)
+	}
+
+	if _, err = TxSub(e2eConfig, tx); err != nil {
+		return fmt.Errorf("not able to submit transaction to merge pool accounts, %w", err)
+	}
+
+	return nil
+}
+
+func mergeAccountsTx(sourceAccount *txnbuild.SimpleAccount, accounts []*keypair.Full) (*txnbuild.Transaction, error) {
+	ops := make([]txnbuild.Operation, 0, len(accounts))
+	for _, kp := range accounts {
+		ops = append(ops, &txnbuild.AccountMerge{
+			Destination:   sourceAccount.AccountID,
+			SourceAccount: kp.Address(),
+		})
+	}
+
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount:        sourceAccount,
+		IncrementSequenceNum: true,
+		Operations:           ops,
+		BaseFee:              txnbuild.MinBaseFee,
+		Preconditions: txnbuild.Preconditions{
+			TimeBounds: txnbuild.NewInfiniteTimeout(),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("building transaction to merge pool accounts had error %v", err)
+	}
+
+	return tx, nil
+}
@@ -0,0 +1,129 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountPoolLease(t *testing.T) {
+	accounts := []*keypair.Full{keypair.MustRandom(), keypair.MustRandom()}
+	pool := newAccountPool(accounts)
+	assert.Equal(t, 2, pool.Size())
+
+	first, err := pool.Lease(context.Background())
+	require.NoError(t, err)
+	second, err := pool.Lease(context.Background())
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Address(), second.Address())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = pool.Lease(ctx)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "got %v", err)
+
+	pool.Return(first)
+	leased, err := pool.Lease(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, first.Address(), leased.Address())
+}
+
+func TestCreateAccountsTx(t *testing.T) {
+	root := keypair.MustRandom()
+	rootAccount := txnbuild.NewSimpleAccount(root.Address(), 10)
+	accounts := []*keypair.Full{keypair.MustRandom(), keypair.MustRandom(), keypair.MustRandom()}
+
+	tx, err := createAccountsTx(&rootAccount, accounts, "1000")
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), tx.SequenceNumber())
+	require.Len(t, tx.Operations(), 3)
+	for i, op := range tx.Operations() {
+		createAccount, ok := op.(*txnbuild.CreateAccount)
+		require.True(t, ok)
+		assert.Equal(t, accounts[i].Address(), createAccount.Destination)
+		assert.Equal(t, "1000", createAccount.Amount)
+	}
+
+	// the next batch follows on in sequence
+	tx, err = createAccountsTx(&rootAccount, accounts[:1], "1000")
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), tx.SequenceNumber())
+}
+
+func TestMergeAccountsTx(t *testing.T) {
+	root := keypair.MustRandom()
+	rootAccount := txnbuild.NewSimpleAccount(root.Address(), 10)
+	accounts := []*keypair.Full{keypair.MustRandom(), keypair.MustRandom()}
+
+	tx, err := mergeAccountsTx(&rootAccount, accounts)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), tx.SequenceNumber())
+	assert.Equal(t, root.Address(), tx.SourceAccount().AccountID)
+	require.Len(t, tx.Operations(), 2)
+	for i, op := range tx.Operations() {
+		merge, ok := op.(*txnbuild.AccountMerge)
+		require.True(t, ok)
+		assert.Equal(t, root.Address(), merge.Destination)
+		assert.Equal(t, accounts[i].Address(), merge.SourceAccount)
+	}
+}
+
+func TestAccountPoolCloseMergesRestWhenOneFails(t *testing.T) {
+	root := keypair.MustRandom()
+	accountXdr, err := xdr.MarshalBase64(xdr.LedgerEntryData{
+		Type:    xdr.LedgerEntryTypeAccount,
+		Account: &xdr.AccountEntry{AccountId: xdr.MustAddress(root.Address()), SeqNum: 100},
+	})
+	require.NoError(t, err)
+
+	accounts := []*keypair.Full{keypair.MustRandom(), keypair.MustRandom(), keypair.MustRandom()}
+	// i.e. a scenario left a trustline on it
+	hasSubEntries := accounts[1].Address()
+
+	var merged []string
+	var pending []string
+	client := newTestRPCServer(t, func(request rpcRequest) interface{} {
+		switch request.Method {
+		case "getLedgerEntries":
+			return LedgerEntriesResult{Entries: []LedgerEntryResult{{XDR: accountXdr}}}
+		case "sendTransaction":
+			var envelope xdr.TransactionEnvelope
+			params := request.Params.(map[string]interface{})
+			require.NoError(t, xdr.SafeUnmarshalBase64(params["transaction"].(string), &envelope))
+			pending = nil
+			for _, op := range envelope.Operations() {
+				pending = append(pending, op.SourceAccount.ToAccountId().Address())
+			}
+			return TransactionResponse{Status: TX_PENDING, LatestLedger: 100}
+		case "getTransaction":
+			for _, address := range pending {
+				if address == hasSubEntries {
+					return TransactionStatusResponse{Status: TX_FAILED, LatestLedger: 101}
+				}
+			}
+			merged = append(merged, pending...)
+			return TransactionStatusResponse{Status: TX_SUCCESS, LatestLedger: 101}
+		}
+		t.Errorf("unexpected rpc method %v", request.Method)
+		return nil
+	})
+
+	pool := newAccountPool(accounts)
+	err = pool.Close(&E2EConfig{
+		TargetNetworkRPCURL:     client.URL,
+		TargetNetworkPassPhrase: testPassphrase,
+		TargetNetworkSecretKey:  root.Seed(),
+		TxPollInterval:          time.Millisecond,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not able to merge 1 pool accounts")
+	assert.Contains(t, err.Error(), hasSubEntries)
+	assert.Equal(t, []string{accounts[0].Address(), accounts[2].Address()}, merged)
+}
@@ -0,0 +1,121 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-cmd/cmd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCommandContext(t *testing.T) {
+	result, err := RunCommandContext(context.Background(), cmd.NewCmd("sh", "-c", "echo one; echo two; echo oops >&2; exit 3"), &E2EConfig{}, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.ExitCode)
+	assert.Equal(t, []string{"one", "two"}, result.Stdout)
+	assert.Equal(t, []string{"oops"}, result.Stderr)
+	assert.Equal(t, "oops", result.StderrTail())
+	assert.Equal(t, `sh -c "echo one; echo two; echo oops >&2; exit 3"`, result.CommandLine)
+	assert.Greater(t, result.Duration, time.Duration(0))
+}
+
+func TestCommandResultStderrTail(t *testing.T) {
+	result := &CommandResult{}
+	for i := 0; i < commandStderrTailLines+5; i++ {
+		result.Stderr = append(result.Stderr, fmt.Sprint(i))
+	}
+
+	tail := strings.Split(result.StderrTail(), "\n")
+	assert.Len(t, tail, commandStderrTailLines)
+	assert.Equal(t, fmt.Sprint(commandStderrTailLines+4), tail[len(tail)-1])
+}
+
+func TestRunCommandContextTimeout(t *testing.T) {
+	start := time.Now()
+	// the background sleep keeps running in the process group unless the whole group is killed
+	result, err := RunCommandContext(context.Background(), cmd.NewCmd("sh", "-c", "echo started; sleep 30 & wait"), &E2EConfig{}, 500*time.Millisecond)
+
+	var timeoutErr *CommandTimeoutError
+	require.True(t, errors.As(err, &timeoutErr))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, []string{"started"}, result.Stdout)
+	assert.Equal(t, []string{"started"}, timeoutErr.Result.Stdout)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestRunCommandContextKillsAfterGracePeriod(t *testing.T) {
+	gracePeriod := commandKillGracePeriod
+	commandKillGracePeriod = 100 * time.Millisecond
+	t.Cleanup(func() { commandKillGracePeriod = gracePeriod })
+
+	start := time.Now()
+	_, err := RunCommandContext(context.Background(), cmd.NewCmd("sh", "-c", "trap '' TERM; sleep 30"), &E2EConfig{}, 200*time.Millisecond)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestRunCommandContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := RunCommandContext(ctx, cmd.NewCmd("sleep", "30"), &E2EConfig{}, 0)
+
+	var timeoutErr *CommandTimeoutError
+	require.True(t, errors.As(err, &timeoutErr))
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// run with -race, many commands in parallel each interleaving stdout and stderr,
+// some stopped part way through by timeouts
+func TestRunCommandContextConcurrent(t *testing.T) {
+	const commands = 20
+	const lines = 200
+
+	var wg sync.WaitGroup
+	for i := 0; i < commands; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			if i%4 == 0 {
+				result, err := RunCommandContext(context.Background(), cmd.NewCmd("sh", "-c", "echo started; echo started >&2; sleep 30"), &E2EConfig{}, 300*time.Millisecond)
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+				assert.Equal(t, []string{"started"}, result.Stdout)
+				assert.Equal(t, []string{"started"}, result.Stderr)
+				return
+			}
+
+			script := fmt.Sprintf("i=0; while [ $i -lt %d ]; do echo out $i; echo err $i >&2; i=$((i+1)); done", lines)
+			result, err := RunCommandContext(context.Background(), cmd.NewCmd("sh", "-c", script), &E2EConfig{}, time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, 0, result.ExitCode)
+			require.Len(t, result.Stdout, lines)
+			require.Len(t, result.Stderr, lines)
+			for line := 0; line < lines; line++ {
+				assert.Equal(t, fmt.Sprintf("out %d", line), result.Stdout[line])
+				assert.Equal(t, fmt.Sprintf("err %d", line), result.Stderr[line])
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestRunCommandWithEnv(t *testing.T) {
+	t.Setenv("E2E_INHERITED", "inherited")
+	config := &E2EConfig{CommandEnv: CommandEnv{"E2E_SCENARIO": "scenario", "E2E_OVERRIDE": "scenario"}}
+
+	result, err := RunCommandWithEnv(cmd.NewCmd("sh", "-c", "echo $E2E_INHERITED $E2E_SCENARIO $E2E_OVERRIDE"), config, CommandEnv{"E2E_OVERRIDE": "command"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"inherited scenario command"}, result.Stdout)
+
+	result, err = RunCommand(cmd.NewCmd("sh", "-c", "echo $E2E_INHERITED $E2E_SCENARIO $E2E_OVERRIDE"), config)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"inherited scenario scenario"}, result.Stdout)
+}
@@ -1,16 +1,21 @@
 package e2e
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-cmd/cmd"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/keypair"
 	"github.com/stellar/go/strkey"
 	"github.com/stellar/go/txnbuild"
 	"github.com/stellar/go/xdr"
@@ -21,23 +26,80 @@ type E2EConfig struct {
 	SorobanExamplesGitHash string
 	SorobanExamplesRepoURL string
 	VerboseOutput          bool
+	// optional local directory of contract sources to build instead of cloning the examples repo
+	ContractsSourcePath string
+	// optional directory of prebuilt contract wasm, when set contracts are not compiled
+	ContractsWasmPath string
+	// if true, example contracts are rebuilt in every scenario rather than reusing the run's cached build
+	ForceContractRebuild bool
+	// if true, a failed scenario's workspace directory is not removed so it can be inspected
+	KeepWorkspaceOnFailure bool
 
 	// target network that test will use
 	TargetNetworkRPCURL     string
 	TargetNetworkPassPhrase string
 	TargetNetworkSecretKey  string
 	TargetNetworkPublicKey  string
+	// number of accounts created and funded from TargetNetworkSecretKey at start, each scenario
+	// leases one and signs with it as its TargetNetworkSecretKey, 0 to sign with the root account
+	AccountPoolSize int
+	// XLM each pool account is funded with
+	AccountPoolBalance string
+	AccountPool        *AccountPool
 	// if true, means the core is running in same container as tests
 	LocalCore bool
 	// the relative feature file path
 	FeaturePath string
+
+	// tx submission confirmation polling, the interval is multiplied by
+	// backoff after each poll, a backoff of 1 keeps the interval fixed
+	TxPollInterval   time.Duration
+	TxConfirmTimeout time.Duration
+	TxPollBackoff    float64
+	// if greater than 0, tx must be confirmed within this many ledgers after submission
+	TxConfirmLedgers uint32
+
+	// max time a command run by the tests may take before its process group is killed,
+	// BuildCommandTimeout applies to the longer running git clone and contract builds
+	CommandTimeout      time.Duration
+	BuildCommandTimeout time.Duration
+	// env vars added to every command run with this config, i.e. a scenario's isolated cli config home
+	CommandEnv CommandEnv
+	// if set, all commands and rpc calls made with this config are recorded to it
+	Transcript *Transcript
+	// directory that per scenario transcripts are written to
+	TranscriptDirectory string
 }
 
+// env vars for a command, added on top of the environment inherited from the test process
+type CommandEnv map[string]string
+
+const (
+	DefaultTxPollInterval      = 3 * time.Second
+	DefaultTxConfirmTimeout    = 30 * time.Second
+	DefaultTxPollBackoff       = 1.0
+	DefaultCommandTimeout      = 5 * time.Minute
+	DefaultBuildCommandTimeout = 20 * time.Minute
+	DefaultAccountPoolSize     = 0
+	DefaultAccountPoolBalance  = "1000"
+)
+
 const (
+	// getTransaction statuses
 	TX_SUCCESS   = "SUCCESS"
 	TX_NOT_FOUND = "NOT_FOUND"
+	TX_FAILED    = "FAILED"
+
+	// sendTransaction statuses
+	TX_PENDING         = "PENDING"
+	TX_DUPLICATE       = "DUPLICATE"
+	TX_TRY_AGAIN_LATER = "TRY_AGAIN_LATER"
+	TX_ERROR           = "ERROR"
 )
 
+// max number of times TxSub will resubmit a tx that got TRY_AGAIN_LATER
+const TxSubMaxResubmits = 5
+
 type RPCError struct {
 	Code    int64  `json:"code"`
 	Message string `json:"message"`
@@ -50,26 +112,246 @@ type AccountInfo struct {
 }
 
 type TransactionResponse struct {
-	ID     string `json:"id"`
-	Status string `json:"status"`
+	Hash                  string `json:"hash"`
+	Status                string `json:"status"`
+	LatestLedger          uint32 `json:"latestLedger"`
+	LatestLedgerCloseTime int64  `json:"latestLedgerCloseTime,string"`
+	// base64 encoded xdr TransactionResult, only present for ERROR status
+	ErrorResultXdr string `json:"errorResultXdr,omitempty"`
+	// base64 encoded xdr DiagnosticEvent, only present for ERROR status
+	DiagnosticEventsXdr []string `json:"diagnosticEventsXdr,omitempty"`
+}
+
+// TxSubError is returned by TxSub when the network rejected the tx on submission,
+// or the tx was included in a ledger but failed.
+type TxSubError struct {
+	TxHash string
+	// the sendTransaction or getTransaction status which reported the failure
+	Status string
+	// tx result code, and the inner tx result code for fee bumps
+	ResultCodes []string
+	// per operation result codes, i.e. op_underfunded
+	OperationResultCodes []string
+	Result               *xdr.TransactionResult
+}
+
+func (e *TxSubError) Error() string {
+	return fmt.Sprintf("tx %v status %v, result codes %v, operation result codes %v", e.TxHash, e.Status, e.ResultCodes, e.OperationResultCodes)
+}
+
+func newTxSubError(txHash string, status string, resultXdr string) *TxSubError {
+	txSubErr := &TxSubError{TxHash: txHash, Status: status}
+	if resultXdr == "" {
+		return txSubErr
+	}
+
+	var result xdr.TransactionResult
+	if err := xdr.SafeUnmarshalBase64(resultXdr, &result); err != nil {
+		txSubErr.ResultCodes = []string{fmt.Sprintf("unparseable result xdr %v", resultXdr)}
+		return txSubErr
+	}
+	txSubErr.Result = &result
+
+	txSubErr.ResultCodes = append(txSubErr.ResultCodes, result.Result.Code.String())
+	if innerResultPair, ok := result.Result.GetInnerResultPair(); ok {
+		txSubErr.ResultCodes = append(txSubErr.ResultCodes, innerResultPair.Result.Result.Code.String())
+	}
+
+	if opResults, ok := result.OperationResults(); ok {
+		for _, opResult := range opResults {
+			txSubErr.OperationResultCodes = append(txSubErr.OperationResultCodes, operationResultCode(opResult))
+		}
+	}
+
+	return txSubErr
 }
 
-type RPCTransactionResponse struct {
-	Result TransactionResponse `json:"result"`
-	Error  *RPCError           `json:"error,omitempty"`
+// returns the operation specific result code when the operation was applied, for the operation
+// types the tests submit, otherwise the generic operation result code or the operation type
+func operationResultCode(opResult xdr.OperationResult) string {
+	tr, ok := opResult.GetTr()
+	if opResult.Code != xdr.OperationResultCodeOpInner || !ok {
+		return opResult.Code.String()
+	}
+
+	switch tr.Type {
+	case xdr.OperationTypeCreateAccount:
+		if result, ok := tr.GetCreateAccountResult(); ok {
+			return result.Code.String()
+		}
+	case xdr.OperationTypePayment:
+		if result, ok := tr.GetPaymentResult(); ok {
+			return result.Code.String()
+		}
+	case xdr.OperationTypeAccountMerge:
+		if result, ok := tr.GetAccountMergeResult(); ok {
+			return result.Code.String()
+		}
+	case xdr.OperationTypeInvokeHostFunction:
+		if result, ok := tr.GetInvokeHostFunctionResult(); ok {
+			return result.Code.String()
+		}
+	case xdr.OperationTypeExtendFootprintTtl:
+		if result, ok := tr.GetExtendFootprintTtlResult(); ok {
+			return result.Code.String()
+		}
+	case xdr.OperationTypeRestoreFootprint:
+		if result, ok := tr.GetRestoreFootprintResult(); ok {
+			return result.Code.String()
+		}
+	}
+	return tr.Type.String()
+}
+
+type TransactionEventsResponse struct {
+	// base64 encoded xdr TransactionEvent
+	TransactionEventsXdr []string `json:"transactionEventsXdr,omitempty"`
+	// base64 encoded xdr ContractEvent, grouped per operation
+	ContractEventsXdr [][]string `json:"contractEventsXdr,omitempty"`
+	// base64 encoded xdr DiagnosticEvent
+	DiagnosticEventsXdr []string `json:"diagnosticEventsXdr,omitempty"`
 }
 
 type TransactionStatusResponse struct {
-	ID            string `json:"id"`
-	Status        string `json:"status"`
-	EnvelopeXdr   string `json:"envelopeXdr,omitempty"`
-	ResultXdr     string `json:"resultXdr,omitempty"`
-	ResultMetaXdr string `json:"resultMetaXdr,omitempty"`
+	ID                    string `json:"txHash"`
+	Status                string `json:"status"`
+	LatestLedger          uint32 `json:"latestLedger"`
+	LatestLedgerCloseTime int64  `json:"latestLedgerCloseTime,string"`
+	OldestLedger          uint32 `json:"oldestLedger"`
+	OldestLedgerCloseTime int64  `json:"oldestLedgerCloseTime,string"`
+	// the remaining fields are only present when the tx was found
+	Ledger           uint32 `json:"ledger,omitempty"`
+	CreatedAt        int64  `json:"createdAt,string,omitempty"`
+	ApplicationOrder int32  `json:"applicationOrder,omitempty"`
+	FeeBump          bool   `json:"feeBump,omitempty"`
+	EnvelopeXdr      string `json:"envelopeXdr,omitempty"`
+	ResultXdr        string `json:"resultXdr,omitempty"`
+	ResultMetaXdr    string `json:"resultMetaXdr,omitempty"`
+	// base64 encoded xdr ScVal, only provided by some rpc versions, otherwise use ReturnValue()
+	ReturnValueXdr string `json:"returnValue,omitempty"`
+	// base64 encoded xdr DiagnosticEvent
+	DiagnosticEventsXdr []string                   `json:"diagnosticEventsXdr,omitempty"`
+	Events              *TransactionEventsResponse `json:"events,omitempty"`
+}
+
+func (r *TransactionStatusResponse) TransactionMeta() (xdr.TransactionMeta, error) {
+	var meta xdr.TransactionMeta
+	if r.ResultMetaXdr == "" {
+		return meta, fmt.Errorf("tx %v with status %v has no result meta", r.ID, r.Status)
+	}
+	if err := xdr.SafeUnmarshalBase64(r.ResultMetaXdr, &meta); err != nil {
+		return meta, fmt.Errorf("tx %v, not able to parse result meta xdr, %w", r.ID, err)
+	}
+	return meta, nil
 }
 
-type RPCTransactionStatusResponse struct {
-	Result TransactionStatusResponse `json:"result"`
-	Error  *RPCError                 `json:"error,omitempty"`
+// returns the soroban host function return value
+func (r *TransactionStatusResponse) ReturnValue() (xdr.ScVal, error) {
+	var returnValue xdr.ScVal
+	if r.ReturnValueXdr != "" {
+		if err := xdr.SafeUnmarshalBase64(r.ReturnValueXdr, &returnValue); err != nil {
+			return returnValue, fmt.Errorf("tx %v, not able to parse return value xdr, %w", r.ID, err)
+		}
+		return returnValue, nil
+	}
+
+	meta, err := r.TransactionMeta()
+	if err != nil {
+		return returnValue, err
+	}
+
+	switch meta.V {
+	case 3:
+		if meta.V3.SorobanMeta != nil {
+			return meta.V3.SorobanMeta.ReturnValue, nil
+		}
+	case 4:
+		if meta.V4.SorobanMeta != nil && meta.V4.SorobanMeta.ReturnValue != nil {
+			return *meta.V4.SorobanMeta.ReturnValue, nil
+		}
+	}
+
+	return returnValue, fmt.Errorf("tx %v, result meta v%v has no soroban return value", r.ID, meta.V)
+}
+
+// returns the contract events emitted by all operations of the tx
+func (r *TransactionStatusResponse) ContractEvents() ([]xdr.ContractEvent, error) {
+	meta, err := r.TransactionMeta()
+	if err != nil {
+		return nil, err
+	}
+
+	operationCount := 1
+	if meta.V == 4 {
+		operationCount = len(meta.V4.Operations)
+	}
+
+	contractEvents := []xdr.ContractEvent{}
+	for opIndex := 0; opIndex < operationCount; opIndex++ {
+		events, err := meta.GetContractEventsForOperation(uint32(opIndex))
+		if err != nil {
+			return nil, fmt.Errorf("tx %v, not able to get contract events, %w", r.ID, err)
+		}
+		contractEvents = append(contractEvents, events...)
+	}
+
+	return contractEvents, nil
+}
+
+// returns the diagnostic events from the result meta, or from the diagnostic events
+// returned separately by rpc if the result meta does not include them
+func (r *TransactionStatusResponse) DiagnosticEvents() ([]xdr.DiagnosticEvent, error) {
+	meta, err := r.TransactionMeta()
+	if err != nil {
+		return nil, err
+	}
+
+	diagnosticEvents, err := meta.GetDiagnosticEvents()
+	if err != nil {
+		return nil, fmt.Errorf("tx %v, not able to get diagnostic events, %w", r.ID, err)
+	}
+	if len(diagnosticEvents) > 0 {
+		return diagnosticEvents, nil
+	}
+
+	diagnosticEventsXdr := r.DiagnosticEventsXdr
+	if len(diagnosticEventsXdr) == 0 && r.Events != nil {
+		diagnosticEventsXdr = r.Events.DiagnosticEventsXdr
+	}
+
+	diagnosticEvents = []xdr.DiagnosticEvent{}
+	for _, b64Event := range diagnosticEventsXdr {
+		var diagnosticEvent xdr.DiagnosticEvent
+		if err := xdr.SafeUnmarshalBase64(b64Event, &diagnosticEvent); err != nil {
+			return nil, fmt.Errorf("tx %v, not able to parse diagnostic event xdr, %w", r.ID, err)
+		}
+		diagnosticEvents = append(diagnosticEvents, diagnosticEvent)
+	}
+
+	return diagnosticEvents, nil
+}
+
+type SimulateHostFunctionResult struct {
+	// base64 encoded xdr SorobanAuthorizationEntry
+	Auth []string `json:"auth"`
+	// base64 encoded xdr ScVal of the host function return value
+	XDR string `json:"xdr"`
+}
+
+type SimulateTransactionResponse struct {
+	Error string `json:"error,omitempty"`
+	// base64 encoded xdr SorobanTransactionData
+	TransactionData string `json:"transactionData,omitempty"`
+	MinResourceFee  int64  `json:"minResourceFee,string,omitempty"`
+	// base64 encoded xdr DiagnosticEvent
+	Events       []string                     `json:"events,omitempty"`
+	Results      []SimulateHostFunctionResult `json:"results,omitempty"`
+	LatestLedger uint32                       `json:"latestLedger"`
+}
+
+type PaginationOptions struct {
+	Cursor string `json:"cursor,omitempty"`
+	Limit  uint   `json:"limit,omitempty"`
 }
 
 type LedgerEntryResult struct {
@@ -80,11 +362,6 @@ type LedgerEntriesResult struct {
 	Entries []LedgerEntryResult `json:"entries"`
 }
 
-type RPCLedgerEntriesResponse struct {
-	Result LedgerEntriesResult `json:"result"`
-	Error  *RPCError           `json:"error,omitempty"`
-}
-
 type LatestLedgerResult struct {
 	// Hash of the latest ledger as a hex-encoded string
 	Hash string `json:"id"`
@@ -94,13 +371,18 @@ type LatestLedgerResult struct {
 	Sequence uint32 `json:"sequence"`
 }
 
-type RPCLatestLedgerResponse struct {
-	Result LatestLedgerResult `json:"result"`
-	Error  *RPCError          `json:"error,omitempty"`
-}
-
+// name prefix of the per scenario workspace directories created under the os temp dir
 const TestTmpDirectory = "test_tmp_workspace"
 
+// creates a uniquely named workspace directory for one scenario, returns its absolute path
+func NewTestWorkspace() (string, error) {
+	dir, err := os.MkdirTemp("", TestTmpDirectory+"_*")
+	if err != nil {
+		return "", fmt.Errorf("could not create %s directory, %v", TestTmpDirectory, err)
+	}
+	return dir, nil
+}
+
 func InitEnvironment() (*E2EConfig, error) {
 	var flagConfig = &E2EConfig{}
 	var err error
@@ -123,16 +405,87 @@ func InitEnvironment() (*E2EConfig, error) {
 	if flagConfig.TargetNetworkSecretKey, err = getEnv("TargetNetworkSecretKey"); err != nil {
 		return nil, err
 	}
+	RegisterSecret(flagConfig.TargetNetworkSecretKey)
 	if flagConfig.TargetNetworkPublicKey, err = getEnv("TargetNetworkPublicKey"); err != nil {
 		return nil, err
 	}
 	if verboseOutput, err := getEnv("VerboseOutput"); err == nil {
 		flagConfig.VerboseOutput, _ = strconv.ParseBool(verboseOutput)
 	}
+	if contractsSourcePath, err := getEnv("ContractsSourcePath"); err == nil {
+		flagConfig.ContractsSourcePath = contractsSourcePath
+	}
+	if contractsWasmPath, err := getEnv("ContractsWasmPath"); err == nil {
+		flagConfig.ContractsWasmPath = contractsWasmPath
+	}
+	flagConfig.TranscriptDirectory = filepath.Join(os.TempDir(), "e2e_transcripts")
+	if transcriptDirectory, err := getEnv("TranscriptDirectory"); err == nil && transcriptDirectory != "" {
+		flagConfig.TranscriptDirectory = transcriptDirectory
+	}
+	if flagConfig.ContractsSourcePath != "" && flagConfig.ContractsWasmPath != "" {
+		return nil, fmt.Errorf("invalid env variables, only one of ContractsSourcePath or ContractsWasmPath may be set")
+	}
+	if forceRebuild, err := getEnv("ForceContractRebuild"); err == nil {
+		flagConfig.ForceContractRebuild, _ = strconv.ParseBool(forceRebuild)
+	}
+	if keepWorkspace, err := getEnv("KeepWorkspaceOnFailure"); err == nil {
+		flagConfig.KeepWorkspaceOnFailure, _ = strconv.ParseBool(keepWorkspace)
+	}
 	if LocalCore, err := getEnv("LocalCore"); err == nil {
 		flagConfig.LocalCore, _ = strconv.ParseBool(LocalCore)
 	}
 
+	flagConfig.TxPollInterval = DefaultTxPollInterval
+	if txPollInterval, err := getEnv("TxPollInterval"); err == nil {
+		if flagConfig.TxPollInterval, err = time.ParseDuration(txPollInterval); err != nil {
+			return nil, fmt.Errorf("invalid env variable TxPollInterval %v, %v", txPollInterval, err)
+		}
+	}
+	flagConfig.TxConfirmTimeout = DefaultTxConfirmTimeout
+	if txConfirmTimeout, err := getEnv("TxConfirmTimeout"); err == nil {
+		if flagConfig.TxConfirmTimeout, err = time.ParseDuration(txConfirmTimeout); err != nil {
+			return nil, fmt.Errorf("invalid env variable TxConfirmTimeout %v, %v", txConfirmTimeout, err)
+		}
+	}
+	flagConfig.TxPollBackoff = DefaultTxPollBackoff
+	if txPollBackoff, err := getEnv("TxPollBackoff"); err == nil {
+		if flagConfig.TxPollBackoff, err = strconv.ParseFloat(txPollBackoff, 64); err != nil || flagConfig.TxPollBackoff < 1 {
+			return nil, fmt.Errorf("invalid env variable TxPollBackoff %v, must be a number of at least 1", txPollBackoff)
+		}
+	}
+	if txConfirmLedgers, err := getEnv("TxConfirmLedgers"); err == nil {
+		confirmLedgers, err := strconv.ParseUint(txConfirmLedgers, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid env variable TxConfirmLedgers %v, %v", txConfirmLedgers, err)
+		}
+		flagConfig.TxConfirmLedgers = uint32(confirmLedgers)
+	}
+	flagConfig.CommandTimeout = DefaultCommandTimeout
+	if commandTimeout, err := getEnv("CommandTimeout"); err == nil {
+		if flagConfig.CommandTimeout, err = time.ParseDuration(commandTimeout); err != nil {
+			return nil, fmt.Errorf("invalid env variable CommandTimeout %v, %v", commandTimeout, err)
+		}
+	}
+	flagConfig.BuildCommandTimeout = DefaultBuildCommandTimeout
+	if buildCommandTimeout, err := getEnv("BuildCommandTimeout"); err == nil {
+		if flagConfig.BuildCommandTimeout, err = time.ParseDuration(buildCommandTimeout); err != nil {
+			return nil, fmt.Errorf("invalid env variable BuildCommandTimeout %v, %v", buildCommandTimeout, err)
+		}
+	}
+	flagConfig.AccountPoolSize = DefaultAccountPoolSize
+	if accountPoolSize, err := getEnv("AccountPoolSize"); err == nil {
+		if flagConfig.AccountPoolSize, err = strconv.Atoi(accountPoolSize); err != nil || flagConfig.AccountPoolSize < 0 {
+			return nil, fmt.Errorf("invalid env variable AccountPoolSize %v, must be a number of at least 0", accountPoolSize)
+		}
+	}
+	flagConfig.AccountPoolBalance = DefaultAccountPoolBalance
+	if accountPoolBalance, err := getEnv("AccountPoolBalance"); err == nil {
+		if _, err = amount.ParseInt64(accountPoolBalance); err != nil {
+			return nil, fmt.Errorf("invalid env variable AccountPoolBalance %v, %v", accountPoolBalance, err)
+		}
+		flagConfig.AccountPoolBalance = accountPoolBalance
+	}
+
 	return flagConfig, nil
 }
 
@@ -140,17 +493,115 @@ type TestContextKey string
 
 var TestConfigContextKey = TestContextKey("TestConfig")
 
-func RunCommand(testCmd *cmd.Cmd, config *E2EConfig) (int, []string, error) {
-	return RunCommandWithStdin(testCmd, config, nil)
+// how long a command gets to exit after SIGTERM before its process group is sent SIGKILL
+var commandKillGracePeriod = 5 * time.Second
+
+// number of trailing stderr lines included in error messages
+const commandStderrTailLines = 20
+
+// the outcome of a command run by the tests
+type CommandResult struct {
+	// the command name and args as a single line with secrets redacted, for reporting
+	CommandLine string
+	ExitCode    int
+	Stdout      []string
+	Stderr      []string
+	Duration    time.Duration
+}
+
+// the last lines of stderr joined as one string with secrets redacted, for including in error messages
+func (r *CommandResult) StderrTail() string {
+	tail := r.Stderr
+	if len(tail) > commandStderrTailLines {
+		tail = tail[len(tail)-commandStderrTailLines:]
+	}
+	return RedactSecrets(strings.Join(tail, "\n"))
+}
+
+func commandLine(testCmd *cmd.Cmd) string {
+	words := []string{testCmd.Name}
+	for _, arg := range testCmd.Args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+			arg = strconv.Quote(arg)
+		}
+		words = append(words, arg)
+	}
+	return strings.Join(words, " ")
+}
+
+// returned when a command is stopped because its timeout expired or its context was cancelled,
+// Unwrap gives the context error so errors.Is(err, context.DeadlineExceeded) identifies a timeout
+type CommandTimeoutError struct {
+	// holds the stdout and stderr captured before the command was stopped
+	Result  *CommandResult
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *CommandTimeoutError) Error() string {
+	reason := fmt.Sprintf("timed out after %v", e.Timeout)
+	if !errors.Is(e.Err, context.DeadlineExceeded) {
+		reason = fmt.Sprintf("was stopped, %v", e.Err)
+	}
+	return RedactSecrets(fmt.Sprintf("command %s %s, output so far: %v, stderr: %v", e.Result.CommandLine, reason, strings.Join(e.Result.Stdout, "\n"), e.Result.StderrTail()))
+}
+
+func (e *CommandTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// runs the command with the config's default CommandTimeout
+func RunCommand(testCmd *cmd.Cmd, config *E2EConfig) (*CommandResult, error) {
+	return runCommand(context.Background(), testCmd, config, config.CommandTimeout, nil, nil)
+}
+
+// runs the command with the config's default CommandTimeout and env vars for just this command,
+// which take precedence over the config's CommandEnv
+func RunCommandWithEnv(testCmd *cmd.Cmd, config *E2EConfig, env CommandEnv) (*CommandResult, error) {
+	return runCommand(context.Background(), testCmd, config, config.CommandTimeout, nil, env)
 }
 
-func RunCommandWithStdin(testCmd *cmd.Cmd, config *E2EConfig, stdin io.Reader) (int, []string, error) {
+// runs the command with the config's default CommandTimeout
+func RunCommandWithStdin(testCmd *cmd.Cmd, config *E2EConfig, stdin io.Reader) (*CommandResult, error) {
+	return runCommand(context.Background(), testCmd, config, config.CommandTimeout, stdin, nil)
+}
+
+// runs the command until it exits, ctx is done or timeout expires, a timeout of 0 means no timeout.
+// a command that is stopped has its whole process group killed and returns *CommandTimeoutError.
+// the returned result is never nil, so output captured up to a failure can be reported.
+func RunCommandContext(ctx context.Context, testCmd *cmd.Cmd, config *E2EConfig, timeout time.Duration) (*CommandResult, error) {
+	return runCommand(ctx, testCmd, config, timeout, nil, nil)
+}
+
+func runCommand(ctx context.Context, testCmd *cmd.Cmd, config *E2EConfig, timeout time.Duration, stdin io.Reader, env CommandEnv) (*CommandResult, error) {
+	start := time.Now()
+	result, err := execCommand(ctx, testCmd, config, timeout, stdin, env)
+	config.Transcript.RecordCommand(start, result, commandEnvOverrides(testCmd.Env, config.CommandEnv, env), err)
+	return result, err
+}
+
+func execCommand(ctx context.Context, testCmd *cmd.Cmd, config *E2EConfig, timeout time.Duration, stdin io.Reader, env CommandEnv) (*CommandResult, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	result := &CommandResult{
+		CommandLine: RedactSecrets(commandLine(testCmd)),
+		ExitCode:    -1,
+		Stdout:      []string{},
+		Stderr:      []string{},
+	}
+
 	// Run, stream output, and wait for Cmd to return Status
 	if config.VerboseOutput {
-		fmt.Printf("running command %s %v \n\n", testCmd.Name, testCmd.Args)
+		fmt.Printf("running command %s %v \n\n", testCmd.Name, redactArgs(testCmd.Args))
 	}
 
-	output := []string{}
+	if ctx.Err() != nil {
+		return result, &CommandTimeoutError{Result: result, Timeout: timeout, Err: ctx.Err()}
+	}
 
 	cmdOptions := cmd.Options{
 		Buffered:  false,
@@ -158,44 +609,110 @@ func RunCommandWithStdin(testCmd *cmd.Cmd, config *E2EConfig, stdin io.Reader) (
 	}
 	envCmd := cmd.NewCmdOptions(cmdOptions, testCmd.Name, testCmd.Args...)
 	envCmd.Dir = testCmd.Dir
+	envCmd.Env = commandEnviron(testCmd.Env, config.CommandEnv, env)
 
-	doneChan := make(chan struct{})
-	go func() {
-		defer close(doneChan)
-		for envCmd.Stdout != nil || envCmd.Stderr != nil {
-			select {
-			case line, open := <-envCmd.Stdout:
-				if !open {
-					envCmd.Stdout = nil
-					continue
-				}
-				if config.VerboseOutput {
-					fmt.Fprintln(os.Stdout, line)
-				}
-				output = append(output, line)
-			case line, open := <-envCmd.Stderr:
-				if !open {
-					envCmd.Stderr = nil
-					continue
-				}
-				if config.VerboseOutput {
-					fmt.Fprintln(os.Stderr, line)
-				}
-			}
-		}
-	}()
+	// the collector goroutine owns the output slices until it hands them back on outputChan,
+	// it only reads from the stream channels and never touches envCmd, so nothing is shared
+	outputChan := make(chan commandOutput, 1)
+	go collectCommandOutput(envCmd.Stdout, envCmd.Stderr, config.VerboseOutput, outputChan)
 
-	// Run and wait for Cmd to return, discard Status
+	start := time.Now()
+	var statusChan <-chan cmd.Status
 	if stdin != nil {
-		<-envCmd.StartWithStdin(stdin)
+		statusChan = envCmd.StartWithStdin(stdin)
 	} else {
-		<-envCmd.Start()
+		statusChan = envCmd.Start()
+	}
+
+	var status cmd.Status
+	var err error
+	select {
+	case status = <-statusChan:
+		err = status.Error
+	case <-ctx.Done():
+		status = stopProcessGroup(envCmd, statusChan)
+		err = &CommandTimeoutError{Result: result, Timeout: timeout, Err: ctx.Err()}
+	}
+
+	// stream channels are closed once the process exits, so this waits for all output to be read
+	output := <-outputChan
+
+	result.ExitCode = status.Exit
+	result.Stdout = output.stdout
+	result.Stderr = output.stderr
+	result.Duration = time.Since(start)
+	return result, err
+}
+
+// the full environment for a command, later entries override earlier ones for the same key.
+// nil when there is nothing to add, so the command just inherits the test process environment.
+func commandEnviron(cmdEnv []string, envs ...CommandEnv) []string {
+	added := []string{}
+	for _, env := range envs {
+		keys := make([]string, 0, len(env))
+		for key := range env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			added = append(added, key+"="+env[key])
+		}
 	}
 
-	// Wait for goroutine to print everything
-	<-doneChan
+	if len(cmdEnv) == 0 && len(added) == 0 {
+		return nil
+	}
+
+	// os/exec uses the last value when a key is repeated
+	environ := append(os.Environ(), cmdEnv...)
+	return append(environ, added...)
+}
 
-	return envCmd.Status().Exit, output, envCmd.Status().Error
+type commandOutput struct {
+	stdout []string
+	stderr []string
+}
+
+// reads both streams until they are closed, then sends everything read on outputChan
+func collectCommandOutput(stdoutChan <-chan string, stderrChan <-chan string, verbose bool, outputChan chan<- commandOutput) {
+	output := commandOutput{stdout: []string{}, stderr: []string{}}
+	for stdoutChan != nil || stderrChan != nil {
+		select {
+		case line, open := <-stdoutChan:
+			if !open {
+				stdoutChan = nil
+				continue
+			}
+			if verbose {
+				fmt.Fprintln(os.Stdout, RedactSecrets(line))
+			}
+			output.stdout = append(output.stdout, line)
+		case line, open := <-stderrChan:
+			if !open {
+				stderrChan = nil
+				continue
+			}
+			if verbose {
+				fmt.Fprintln(os.Stderr, RedactSecrets(line))
+			}
+			output.stderr = append(output.stderr, line)
+		}
+	}
+	outputChan <- output
+}
+
+// sends SIGTERM to the command's process group, then SIGKILL if it has not exited within the grace period,
+// returns the final status of the command
+func stopProcessGroup(envCmd *cmd.Cmd, statusChan <-chan cmd.Status) cmd.Status {
+	_ = envCmd.Stop()
+
+	select {
+	case status := <-statusChan:
+		return status
+	case <-time.After(commandKillGracePeriod):
+		_ = killProcessGroup(envCmd.Status().PID)
+		return <-statusChan
+	}
 }
 
 // asserter is used to be able to retrieve the error reported by the called assertion
@@ -209,29 +726,12 @@ func (a *Asserter) Errorf(format string, args ...interface{}) {
 }
 
 func QueryNetworkState(e2eConfig *E2EConfig) (LatestLedgerResult, error) {
-	getLatestLedger := []byte(`{
-           "jsonrpc": "2.0",
-           "id": 10235,
-           "method": "getLatestLedger"
-        }`)
-
-	resp, err := http.Post(e2eConfig.TargetNetworkRPCURL, "application/json", bytes.NewBuffer(getLatestLedger))
+	latestLedger, err := NewRPCClient(e2eConfig).GetLatestLedger(context.Background())
 	if err != nil {
-		return LatestLedgerResult{}, fmt.Errorf("soroban rpc get latest ledger had error %e", err)
-	}
-
-	var rpcResponse RPCLatestLedgerResponse
-	decoder := json.NewDecoder(resp.Body)
-	err = decoder.Decode(&rpcResponse)
-	if err != nil {
-		return LatestLedgerResult{}, fmt.Errorf("soroban rpc get latest ledger, not able to parse response, %v, %e", resp, err)
-	}
-	if rpcResponse.Error != nil {
-		return LatestLedgerResult{}, fmt.Errorf("soroban rpc get latest ledger, error on response, %v, %e", resp, err)
+		return LatestLedgerResult{}, fmt.Errorf("soroban rpc get latest ledger had error %w", err)
 	}
 
-	return rpcResponse.Result, nil
-
+	return latestLedger, nil
 }
 
 func QueryAccount(e2eConfig *E2EConfig, publicKey string) (*AccountInfo, error) {
@@ -254,133 +754,235 @@ func QueryAccount(e2eConfig *E2EConfig, publicKey string) (*AccountInfo, error)
 		return nil, fmt.Errorf("error encoding account ledger key xdr: %v", err)
 	}
 
-	getAccountRequest := []byte(`{
-           "jsonrpc": "2.0",
-           "id": 10235,
-           "method": "getLedgerEntries",
-           "params": { 
-               "keys": [` + fmt.Sprintf("%q", keyXdr) + `]
-            }
-        }`)
-
-	resp, err := http.Post(e2eConfig.TargetNetworkRPCURL, "application/json", bytes.NewBuffer(getAccountRequest))
-	if err != nil {
-		return nil, fmt.Errorf("soroban rpc get account had error %e", err)
-	}
-
-	var rpcResponse RPCLedgerEntriesResponse
-	decoder := json.NewDecoder(resp.Body)
-	err = decoder.Decode(&rpcResponse)
+	ledgerEntries, err := NewRPCClient(e2eConfig).GetLedgerEntries(context.Background(), []string{keyXdr})
 	if err != nil {
-		return nil, fmt.Errorf("soroban rpc get account, not able to parse ledger entry response, %v, %e", resp, err)
-	}
-	if rpcResponse.Error != nil {
-		return nil, fmt.Errorf("soroban rpc get account, error on ledger entry response, %v, %e", resp, err)
+		return nil, fmt.Errorf("soroban rpc get account had error %w", err)
 	}
 
 	var entry xdr.LedgerEntryData
-	if len(rpcResponse.Result.Entries) == 0 {
-		return nil, fmt.Errorf("unable to find account for key %v, %e", keyXdr, err)
+	if len(ledgerEntries.Entries) == 0 {
+		return nil, fmt.Errorf("unable to find account for key %v", keyXdr)
 	}
-	err = xdr.SafeUnmarshalBase64(rpcResponse.Result.Entries[0].XDR, &entry)
+	err = xdr.SafeUnmarshalBase64(ledgerEntries.Entries[0].XDR, &entry)
 	if err != nil {
-		return nil, fmt.Errorf("soroban rpc get account, not able to parse XDR from ledger entry response, %v, %e", rpcResponse.Result.Entries[0].XDR, err)
+		return nil, fmt.Errorf("soroban rpc get account, not able to parse XDR from ledger entry response, %v, %w", ledgerEntries.Entries[0].XDR, err)
 	}
 
 	return &AccountInfo{ID: entry.Account.AccountId.Address(), Sequence: int64(entry.Account.SeqNum)}, nil
 }
 
-func QueryTxStatus(e2eConfig *E2EConfig, txHashId string) (*TransactionStatusResponse, error) {
-	getTxStatusRequest := []byte(`{
-           "jsonrpc": "2.0",
-           "id": 10235,
-           "method": "getTransaction",
-           "params": { 
-               "hash": "` + txHashId + `"
-            }
-        }`)
-
-	resp, err := http.Post(e2eConfig.TargetNetworkRPCURL, "application/json", bytes.NewBuffer(getTxStatusRequest))
+// returns the wasm bytes stored on the network in the ContractCode ledger entry for the hex encoded wasm hash
+func QueryContractCode(e2eConfig *E2EConfig, wasmHash string) ([]byte, error) {
+	var hash xdr.Hash
+	decodedHash, err := hex.DecodeString(wasmHash)
+	if err != nil || len(decodedHash) != len(hash) {
+		return nil, fmt.Errorf("invalid wasm hash %v", wasmHash)
+	}
+	copy(hash[:], decodedHash)
+
+	keyXdr, err := xdr.LedgerKey{
+		Type:         xdr.LedgerEntryTypeContractCode,
+		ContractCode: &xdr.LedgerKeyContractCode{Hash: hash},
+	}.MarshalBinaryBase64()
 	if err != nil {
-		return nil, fmt.Errorf("soroban rpc get tx status had error %e", err)
+		return nil, fmt.Errorf("error encoding contract code ledger key xdr: %v", err)
 	}
 
-	var rpcResponse RPCTransactionStatusResponse
-	decoder := json.NewDecoder(resp.Body)
-	err = decoder.Decode(&rpcResponse)
+	ledgerEntries, err := NewRPCClient(e2eConfig).GetLedgerEntries(context.Background(), []string{keyXdr})
+	if err != nil {
+		return nil, fmt.Errorf("soroban rpc get contract code had error %w", err)
+	}
 
+	var entry xdr.LedgerEntryData
+	if len(ledgerEntries.Entries) == 0 {
+		return nil, fmt.Errorf("unable to find contract code for wasm hash %v", wasmHash)
+	}
+	err = xdr.SafeUnmarshalBase64(ledgerEntries.Entries[0].XDR, &entry)
 	if err != nil {
-		return nil, fmt.Errorf("soroban rpc get tx status, not able to parse response, %v, %e", resp.Body, err)
+		return nil, fmt.Errorf("soroban rpc get contract code, not able to parse XDR from ledger entry response, %v, %w", ledgerEntries.Entries[0].XDR, err)
 	}
+	if entry.ContractCode == nil {
+		return nil, fmt.Errorf("soroban rpc get contract code, ledger entry for wasm hash %v was type %v", wasmHash, entry.Type)
+	}
+
+	return entry.ContractCode.Code, nil
+}
 
-	if rpcResponse.Error != nil {
-		return nil, fmt.Errorf("soroban rpc get tx status, got error response, %v", rpcResponse)
+func QueryTxStatus(e2eConfig *E2EConfig, txHashId string) (*TransactionStatusResponse, error) {
+	transactionStatusResponse, err := NewRPCClient(e2eConfig).GetTransaction(context.Background(), txHashId)
+	if err != nil {
+		return nil, fmt.Errorf("soroban rpc get tx status had error %w", err)
 	}
 
-	return &rpcResponse.Result, nil
+	return transactionStatusResponse, nil
 }
 
+// submits the tx and polls until it is included in a ledger.
+// returns *TxSubError if the tx was rejected on submission or failed in the ledger.
 func TxSub(e2eConfig *E2EConfig, tx *txnbuild.Transaction) (*TransactionStatusResponse, error) {
 	b64, err := tx.Base64()
 	if err != nil {
-		return nil, fmt.Errorf("soroban rpc tx sub, not able to serialize tx, %v, %e", tx, err)
+		return nil, fmt.Errorf("soroban rpc tx sub, not able to serialize tx, %v, %w", tx, err)
 	}
 
-	txsubRequest := []byte(`{
-           "jsonrpc": "2.0",
-           "id": 10235,
-           "method": "sendTransaction",
-           "params": { 
-               "transaction": "` + b64 + `"
-            }
-        }`)
-
-	resp, err := http.Post(e2eConfig.TargetNetworkRPCURL, "application/json", bytes.NewBuffer(txsubRequest))
+	txHashId, err := tx.HashHex(e2eConfig.TargetNetworkPassPhrase)
 	if err != nil {
-		return nil, fmt.Errorf("soroban rpc tx sub had error %e", err)
+		return nil, fmt.Errorf("soroban rpc tx sub, not able to generate tx hash id, %v, %w", tx, err)
 	}
 
-	var rpcResponse RPCTransactionResponse
-	decoder := json.NewDecoder(resp.Body)
+	pollInterval, confirmTimeout, pollBackoff := txSubPollSettings(e2eConfig)
 
-	err = decoder.Decode(&rpcResponse)
-	if err != nil {
-		return nil, fmt.Errorf("soroban rpc tx sub, not able to parse response, %v, %e", resp.Body, err)
-	}
+	rpcClient := NewRPCClient(e2eConfig)
+	var txResponse *TransactionResponse
+	for resubmits := 0; ; resubmits++ {
+		txResponse, err = rpcClient.SendTransaction(context.Background(), b64)
+		if err != nil {
+			return nil, fmt.Errorf("soroban rpc tx sub had error %w", err)
+		}
 
-	if rpcResponse.Error != nil {
-		return nil, fmt.Errorf("soroban rpc tx sub, got bad submission response, %v", rpcResponse)
+		if txResponse.Status != TX_TRY_AGAIN_LATER {
+			break
+		}
+		if resubmits >= TxSubMaxResubmits {
+			return nil, newTxSubError(txHashId, txResponse.Status, "")
+		}
+		time.Sleep(pollInterval)
 	}
 
-	txHashId, err := tx.HashHex(e2eConfig.TargetNetworkPassPhrase)
-	if err != nil {
-		return nil, fmt.Errorf("soroban rpc tx sub, not able to generate tx hash id, %v, %e", tx, err)
+	switch txResponse.Status {
+	case TX_PENDING, TX_DUPLICATE:
+		// duplicate means same tx was already submitted, poll for its outcome
+	case TX_ERROR:
+		return nil, newTxSubError(txHashId, txResponse.Status, txResponse.ErrorResultXdr)
+	default:
+		return nil, fmt.Errorf("soroban rpc tx sub, unknown submission status %v, %v", txResponse.Status, txResponse)
 	}
 
-	start := time.Now().Unix()
-	ticker := time.NewTicker(3 * time.Second)
-	defer ticker.Stop()
-	for x := range ticker.C {
-		if x.Unix()-start > 30 {
-			break
-		}
+	deadline := time.Now().Add(confirmTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(pollInterval)
+		pollInterval = time.Duration(float64(pollInterval) * pollBackoff)
 
-		transactionStatusResponse, err := QueryTxStatus(e2eConfig, txHashId)
+		transactionStatusResponse, err := rpcClient.GetTransaction(context.Background(), txHashId)
 		if err != nil {
-			return nil, fmt.Errorf("soroban rpc tx sub, unable to call tx status check, %v, %e", rpcResponse, err)
+			return nil, fmt.Errorf("soroban rpc tx sub, unable to call tx status check, %v, %w", txResponse, err)
 		}
 
 		switch transactionStatusResponse.Status {
 		case TX_SUCCESS:
 			return transactionStatusResponse, nil
 		case TX_NOT_FOUND:
+			if e2eConfig.TxConfirmLedgers > 0 && transactionStatusResponse.LatestLedger > txResponse.LatestLedger+e2eConfig.TxConfirmLedgers {
+				return nil, fmt.Errorf("soroban rpc tx sub, tx %v not confirmed within %v ledgers of submission at ledger %v", txHashId, e2eConfig.TxConfirmLedgers, txResponse.LatestLedger)
+			}
 			// no-op. Retry.
+		case TX_FAILED:
+			return nil, newTxSubError(txHashId, transactionStatusResponse.Status, transactionStatusResponse.ResultXdr)
 		default:
-			return nil, fmt.Errorf("soroban rpc tx sub, got bad response on tx status check, %v, %v", rpcResponse, transactionStatusResponse)
+			return nil, fmt.Errorf("soroban rpc tx sub, got unknown status on tx status check, %v, %v", txResponse, transactionStatusResponse)
+		}
+	}
+
+	return nil, fmt.Errorf("soroban rpc tx sub, timeout after %v on tx status check, %v", confirmTimeout, txResponse)
+}
+
+// defaults any unset polling settings, for configs not created by InitEnvironment
+func txSubPollSettings(e2eConfig *E2EConfig) (time.Duration, time.Duration, float64) {
+	pollInterval, confirmTimeout, pollBackoff := e2eConfig.TxPollInterval, e2eConfig.TxConfirmTimeout, e2eConfig.TxPollBackoff
+	if pollInterval <= 0 {
+		pollInterval = DefaultTxPollInterval
+	}
+	if confirmTimeout <= 0 {
+		confirmTimeout = DefaultTxConfirmTimeout
+	}
+	if pollBackoff < 1 {
+		pollBackoff = DefaultTxPollBackoff
+	}
+	return pollInterval, confirmTimeout, pollBackoff
+}
+
+func SimulateTransaction(e2eConfig *E2EConfig, tx *txnbuild.Transaction) (*SimulateTransactionResponse, error) {
+	b64, err := tx.Base64()
+	if err != nil {
+		return nil, fmt.Errorf("soroban rpc simulate tx, not able to serialize tx, %v, %w", tx, err)
+	}
+
+	simulateResponse, err := NewRPCClient(e2eConfig).SimulateTransaction(context.Background(), b64)
+	if err != nil {
+		return nil, fmt.Errorf("soroban rpc simulate tx had error %w", err)
+	}
+
+	return simulateResponse, nil
+}
+
+// builds a transaction for the invoke host function operation, simulates it and
+// applies the simulated soroban transaction data, auth entries and resource fee
+// onto the operation, then returns the rebuilt transaction signed by signer.
+// the sequence number of sourceAccount is incremented once.
+func PrepareTransaction(e2eConfig *E2EConfig, sourceAccount txnbuild.Account, op *txnbuild.InvokeHostFunction, signer *keypair.Full) (*txnbuild.Transaction, *SimulateTransactionResponse, error) {
+	txParams := txnbuild.TransactionParams{
+		SourceAccount:        sourceAccount,
+		IncrementSequenceNum: true,
+		Operations:           []txnbuild.Operation{op},
+		BaseFee:              txnbuild.MinBaseFee,
+		Preconditions: txnbuild.Preconditions{
+			TimeBounds: txnbuild.NewInfiniteTimeout(),
+		},
+	}
+
+	tx, err := txnbuild.NewTransaction(txParams)
+	if err != nil {
+		return nil, nil, fmt.Errorf("soroban prepare tx, not able to build tx for simulation, %w", err)
+	}
+
+	simulateResponse, err := SimulateTransaction(e2eConfig, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if simulateResponse.Error != "" {
+		return nil, simulateResponse, fmt.Errorf("soroban prepare tx, simulation failed, %v", simulateResponse.Error)
+	}
+
+	var sorobanData xdr.SorobanTransactionData
+	if err = xdr.SafeUnmarshalBase64(simulateResponse.TransactionData, &sorobanData); err != nil {
+		return nil, simulateResponse, fmt.Errorf("soroban prepare tx, not able to parse simulated transaction data, %v, %w", simulateResponse.TransactionData, err)
+	}
+	if xdr.Int64(simulateResponse.MinResourceFee) > sorobanData.ResourceFee {
+		sorobanData.ResourceFee = xdr.Int64(simulateResponse.MinResourceFee)
+	}
+
+	if len(op.Auth) == 0 && len(simulateResponse.Results) > 0 {
+		for _, b64Auth := range simulateResponse.Results[0].Auth {
+			var authEntry xdr.SorobanAuthorizationEntry
+			if err = xdr.SafeUnmarshalBase64(b64Auth, &authEntry); err != nil {
+				return nil, simulateResponse, fmt.Errorf("soroban prepare tx, not able to parse simulated auth entry, %v, %w", b64Auth, err)
+			}
+			op.Auth = append(op.Auth, authEntry)
 		}
 	}
 
-	return nil, fmt.Errorf("soroban rpc tx sub, timeout after 30 seconds on tx status check, %v", rpcResponse)
+	op.Ext = xdr.TransactionExt{
+		V:           1,
+		SorobanData: &sorobanData,
+	}
+
+	// source account was already incremented by the simulation build
+	txSourceAccount := tx.SourceAccount()
+	txParams.SourceAccount = &txSourceAccount
+	txParams.IncrementSequenceNum = false
+
+	tx, err = txnbuild.NewTransaction(txParams)
+	if err != nil {
+		return nil, simulateResponse, fmt.Errorf("soroban prepare tx, not able to build prepared tx, %w", err)
+	}
+
+	tx, err = tx.Sign(e2eConfig.TargetNetworkPassPhrase, signer)
+	if err != nil {
+		return nil, simulateResponse, fmt.Errorf("soroban prepare tx, not able to sign prepared tx, %w", err)
+	}
+
+	return tx, simulateResponse, nil
 }
 
 func getEnv(key string) (string, error) {
@@ -0,0 +1,240 @@
+package e2e
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTxSubErrorDecodesResultCodes(t *testing.T) {
+	opResults := []xdr.OperationResult{
+		{
+			Code: xdr.OperationResultCodeOpInner,
+			Tr: &xdr.OperationResultTr{
+				Type:                xdr.OperationTypeCreateAccount,
+				CreateAccountResult: &xdr.CreateAccountResult{Code: xdr.CreateAccountResultCodeCreateAccountUnderfunded},
+			},
+		},
+		{
+			Code: xdr.OperationResultCodeOpInner,
+			Tr: &xdr.OperationResultTr{
+				Type:                     xdr.OperationTypeInvokeHostFunction,
+				InvokeHostFunctionResult: &xdr.InvokeHostFunctionResult{Code: xdr.InvokeHostFunctionResultCodeInvokeHostFunctionTrapped},
+			},
+		},
+		{
+			Code: xdr.OperationResultCodeOpInner,
+			Tr: &xdr.OperationResultTr{
+				Type:             xdr.OperationTypeManageData,
+				ManageDataResult: &xdr.ManageDataResult{Code: xdr.ManageDataResultCodeManageDataNotSupportedYet},
+			},
+		},
+		{Code: xdr.OperationResultCodeOpBadAuth},
+	}
+	resultXdr, err := xdr.MarshalBase64(xdr.TransactionResult{
+		FeeCharged: 100,
+		Result: xdr.TransactionResultResult{
+			Code:    xdr.TransactionResultCodeTxFailed,
+			Results: &opResults,
+		},
+	})
+	require.NoError(t, err)
+
+	var txErr error = newTxSubError("abc", TX_FAILED, resultXdr)
+
+	var txSubErr *TxSubError
+	require.True(t, errors.As(txErr, &txSubErr))
+	assert.Equal(t, TX_FAILED, txSubErr.Status)
+	assert.Equal(t, []string{xdr.TransactionResultCodeTxFailed.String()}, txSubErr.ResultCodes)
+	assert.Equal(t, []string{
+		xdr.CreateAccountResultCodeCreateAccountUnderfunded.String(),
+		xdr.InvokeHostFunctionResultCodeInvokeHostFunctionTrapped.String(),
+		xdr.OperationTypeManageData.String(),
+		xdr.OperationResultCodeOpBadAuth.String(),
+	}, txSubErr.OperationResultCodes)
+	assert.NotNil(t, txSubErr.Result)
+}
+
+func TestTransactionStatusResponseDecodesMeta(t *testing.T) {
+	returnValue := xdr.Uint32(1)
+	topic := xdr.ScSymbol("COUNTER")
+	contractEvent := xdr.ContractEvent{
+		Type: xdr.ContractEventTypeContract,
+		Body: xdr.ContractEventBody{
+			V: 0,
+			V0: &xdr.ContractEventV0{
+				Topics: []xdr.ScVal{{Type: xdr.ScValTypeScvSymbol, Sym: &topic}},
+				Data:   xdr.ScVal{Type: xdr.ScValTypeScvU32, U32: &returnValue},
+			},
+		},
+	}
+	resultMetaXdr, err := xdr.MarshalBase64(xdr.TransactionMeta{
+		V: 4,
+		V4: &xdr.TransactionMetaV4{
+			Operations: []xdr.OperationMetaV2{{Events: []xdr.ContractEvent{contractEvent}}},
+			SorobanMeta: &xdr.SorobanTransactionMetaV2{
+				ReturnValue: &xdr.ScVal{Type: xdr.ScValTypeScvU32, U32: &returnValue},
+			},
+			DiagnosticEvents: []xdr.DiagnosticEvent{{InSuccessfulContractCall: true, Event: contractEvent}},
+		},
+	})
+	require.NoError(t, err)
+
+	txStatus := TransactionStatusResponse{ID: "abc", Status: TX_SUCCESS, ResultMetaXdr: resultMetaXdr}
+
+	value, err := txStatus.ReturnValue()
+	require.NoError(t, err)
+	assert.Equal(t, xdr.Uint32(1), *value.U32)
+
+	contractEvents, err := txStatus.ContractEvents()
+	require.NoError(t, err)
+	require.Len(t, contractEvents, 1)
+	assert.Equal(t, topic, *contractEvents[0].Body.V0.Topics[0].Sym)
+
+	diagnosticEvents, err := txStatus.DiagnosticEvents()
+	require.NoError(t, err)
+	require.Len(t, diagnosticEvents, 1)
+	assert.True(t, diagnosticEvents[0].InSuccessfulContractCall)
+}
+
+func TestQueryContractCode(t *testing.T) {
+	wasm := []byte("\x00asm\x01\x00\x00\x00")
+	hash := xdr.Hash(sha256.Sum256(wasm))
+	entryXdr, err := xdr.MarshalBase64(xdr.LedgerEntryData{
+		Type:         xdr.LedgerEntryTypeContractCode,
+		ContractCode: &xdr.ContractCodeEntry{Hash: hash, Code: wasm},
+	})
+	require.NoError(t, err)
+
+	client := newTestRPCServer(t, func(request rpcRequest) interface{} {
+		assert.Equal(t, "getLedgerEntries", request.Method)
+		return LedgerEntriesResult{Entries: []LedgerEntryResult{{XDR: entryXdr}}}
+	})
+
+	code, err := QueryContractCode(&E2EConfig{TargetNetworkRPCURL: client.URL}, hex.EncodeToString(hash[:]))
+	require.NoError(t, err)
+	assert.Equal(t, wasm, code)
+
+	_, err = QueryContractCode(&E2EConfig{TargetNetworkRPCURL: client.URL}, "not a hash")
+	assert.Error(t, err)
+}
+
+func TestTxSubPollSettings(t *testing.T) {
+	pollInterval, confirmTimeout, pollBackoff := txSubPollSettings(&E2EConfig{})
+	assert.Equal(t, DefaultTxPollInterval, pollInterval)
+	assert.Equal(t, DefaultTxConfirmTimeout, confirmTimeout)
+	assert.Equal(t, DefaultTxPollBackoff, pollBackoff)
+
+	pollInterval, confirmTimeout, pollBackoff = txSubPollSettings(&E2EConfig{TxPollInterval: time.Second, TxConfirmTimeout: time.Minute, TxPollBackoff: 2})
+	assert.Equal(t, time.Second, pollInterval)
+	assert.Equal(t, time.Minute, confirmTimeout)
+	assert.Equal(t, 2.0, pollBackoff)
+}
+
+const testPassphrase = "Test SDF Network ; September 2015"
+
+func newTestTx(t *testing.T) *txnbuild.Transaction {
+	source := txnbuild.NewSimpleAccount(keypair.MustRandom().Address(), 1)
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount:        &source,
+		IncrementSequenceNum: true,
+		Operations:           []txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 10}},
+		BaseFee:              txnbuild.MinBaseFee,
+		Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewInfiniteTimeout()},
+	})
+	require.NoError(t, err)
+	return tx
+}
+
+func TestTxSubPollsWithBackoffUntilSuccess(t *testing.T) {
+	var mu sync.Mutex
+	var sends int
+	var polls []time.Time
+	client := newTestRPCServer(t, func(request rpcRequest) interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		switch request.Method {
+		case "sendTransaction":
+			sends++
+			if sends == 1 {
+				return TransactionResponse{Status: TX_TRY_AGAIN_LATER, LatestLedger: 100}
+			}
+			return TransactionResponse{Status: TX_PENDING, LatestLedger: 100}
+		case "getTransaction":
+			polls = append(polls, time.Now())
+			if len(polls) < 3 {
+				return TransactionStatusResponse{Status: TX_NOT_FOUND, LatestLedger: 101}
+			}
+			return TransactionStatusResponse{Status: TX_SUCCESS, LatestLedger: 102, Ledger: 102}
+		}
+		t.Errorf("unexpected rpc method %v", request.Method)
+		return nil
+	})
+
+	config := &E2EConfig{TargetNetworkRPCURL: client.URL, TargetNetworkPassPhrase: testPassphrase, TxPollInterval: 20 * time.Millisecond, TxConfirmTimeout: 10 * time.Second, TxPollBackoff: 3}
+	status, err := TxSub(config, newTestTx(t))
+	require.NoError(t, err)
+	assert.Equal(t, TX_SUCCESS, status.Status)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, sends, "resubmits after TRY_AGAIN_LATER")
+	require.Len(t, polls, 3)
+	// intervals grow 20ms, 60ms, 180ms with a backoff of 3
+	assert.GreaterOrEqual(t, polls[1].Sub(polls[0]), 60*time.Millisecond)
+	assert.GreaterOrEqual(t, polls[2].Sub(polls[1]), 180*time.Millisecond)
+}
+
+func TestTxSubConfirmLedgersLimit(t *testing.T) {
+	client := newTestRPCServer(t, func(request rpcRequest) interface{} {
+		if request.Method == "sendTransaction" {
+			return TransactionResponse{Status: TX_PENDING, LatestLedger: 100}
+		}
+		return TransactionStatusResponse{Status: TX_NOT_FOUND, LatestLedger: 106}
+	})
+
+	config := &E2EConfig{TargetNetworkRPCURL: client.URL, TargetNetworkPassPhrase: testPassphrase, TxPollInterval: 10 * time.Millisecond, TxConfirmTimeout: 10 * time.Second, TxConfirmLedgers: 5}
+	start := time.Now()
+	_, err := TxSub(config, newTestTx(t))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not confirmed within 5 ledgers of submission at ledger 100")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestTxSubConfirmTimeout(t *testing.T) {
+	client := newTestRPCServer(t, func(request rpcRequest) interface{} {
+		if request.Method == "sendTransaction" {
+			return TransactionResponse{Status: TX_PENDING, LatestLedger: 100}
+		}
+		return TransactionStatusResponse{Status: TX_NOT_FOUND, LatestLedger: 101}
+	})
+
+	config := &E2EConfig{TargetNetworkRPCURL: client.URL, TargetNetworkPassPhrase: testPassphrase, TxPollInterval: 10 * time.Millisecond, TxConfirmTimeout: 100 * time.Millisecond}
+	_, err := TxSub(config, newTestTx(t))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timeout after 100ms")
+}
+
+func TestTxSubFailed(t *testing.T) {
+	client := newTestRPCServer(t, func(request rpcRequest) interface{} {
+		if request.Method == "sendTransaction" {
+			return TransactionResponse{Status: TX_PENDING, LatestLedger: 100}
+		}
+		return TransactionStatusResponse{Status: TX_FAILED, LatestLedger: 101}
+	})
+
+	config := &E2EConfig{TargetNetworkRPCURL: client.URL, TargetNetworkPassPhrase: testPassphrase, TxPollInterval: 10 * time.Millisecond}
+	_, err := TxSub(config, newTestTx(t))
+	var txSubErr *TxSubError
+	require.True(t, errors.As(err, &txSubErr), "got %v", err)
+	assert.Equal(t, TX_FAILED, txSubErr.Status)
+}
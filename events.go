@@ -0,0 +1,161 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"iter"
+
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	EventTypeContract   = "contract"
+	EventTypeSystem     = "system"
+	EventTypeDiagnostic = "diagnostic"
+
+	// topic segment matchers, matches exactly one segment, or zero or more trailing segments
+	TopicSegmentWildcard      = "*"
+	TopicSegmentMultiWildcard = "**"
+)
+
+// an event matches a filter if it matches all of the populated fields.
+// an event matches one of Topics if each topic segment matches the same positioned
+// segment matcher, which is a base64 encoded xdr ScVal or a wildcard.
+type EventFilter struct {
+	EventType   string     `json:"type,omitempty"`
+	ContractIDs []string   `json:"contractIds,omitempty"`
+	Topics      [][]string `json:"topics,omitempty"`
+}
+
+// returns the topic segment matcher for an exact ScVal
+func TopicSegment(value xdr.ScVal) (string, error) {
+	return xdr.MarshalBase64(value)
+}
+
+// returns the topic segment matcher for an exact symbol, i.e. COUNTER
+func SymbolTopicSegment(symbol string) (string, error) {
+	scSymbol := xdr.ScSymbol(symbol)
+	return TopicSegment(xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &scSymbol})
+}
+
+// StartLedger must not be set when Pagination has a Cursor.
+// EndLedger is exclusive and optional.
+type GetEventsRequest struct {
+	StartLedger uint32             `json:"startLedger,omitempty"`
+	EndLedger   uint32             `json:"endLedger,omitempty"`
+	Filters     []EventFilter      `json:"filters"`
+	Pagination  *PaginationOptions `json:"pagination,omitempty"`
+}
+
+type EventInfo struct {
+	EventType                string `json:"type"`
+	Ledger                   int32  `json:"ledger"`
+	LedgerClosedAt           string `json:"ledgerClosedAt"`
+	ContractID               string `json:"contractId"`
+	ID                       string `json:"id"`
+	InSuccessfulContractCall bool   `json:"inSuccessfulContractCall"`
+	TransactionHash          string `json:"txHash"`
+	// base64 encoded xdr ScVal of each topic
+	Topic []string `json:"topic"`
+	// base64 encoded xdr ScVal
+	Value string `json:"value"`
+}
+
+// ContractEvent is an event from getEvents, with the topic and value xdr decoded
+type ContractEvent struct {
+	EventType  string
+	Ledger     int32
+	ContractID string
+	Topic      []xdr.ScVal
+	Value      xdr.ScVal
+	TxHash     string
+}
+
+func (e EventInfo) ContractEvent() (ContractEvent, error) {
+	contractEvent := ContractEvent{
+		EventType:  e.EventType,
+		Ledger:     e.Ledger,
+		ContractID: e.ContractID,
+		Topic:      []xdr.ScVal{},
+		TxHash:     e.TransactionHash,
+	}
+
+	for _, b64Topic := range e.Topic {
+		var topic xdr.ScVal
+		if err := xdr.SafeUnmarshalBase64(b64Topic, &topic); err != nil {
+			return contractEvent, fmt.Errorf("event %v, not able to parse topic xdr %v, %w", e.ID, b64Topic, err)
+		}
+		contractEvent.Topic = append(contractEvent.Topic, topic)
+	}
+
+	if err := xdr.SafeUnmarshalBase64(e.Value, &contractEvent.Value); err != nil {
+		return contractEvent, fmt.Errorf("event %v, not able to parse value xdr %v, %w", e.ID, e.Value, err)
+	}
+
+	return contractEvent, nil
+}
+
+// decodes the events, which may be from rpc or from the json output of any tool
+// that emits events in the same shape as rpc
+func ContractEventsFromEventInfos(events []EventInfo) ([]ContractEvent, error) {
+	contractEvents := []ContractEvent{}
+	for _, event := range events {
+		contractEvent, err := event.ContractEvent()
+		if err != nil {
+			return nil, err
+		}
+		contractEvents = append(contractEvents, contractEvent)
+	}
+	return contractEvents, nil
+}
+
+type GetEventsResponse struct {
+	Events       []EventInfo `json:"events"`
+	LatestLedger uint32      `json:"latestLedger"`
+	Cursor       string      `json:"cursor,omitempty"`
+}
+
+func GetEvents(e2eConfig *E2EConfig, request GetEventsRequest) (*GetEventsResponse, error) {
+	response, err := NewRPCClient(e2eConfig).GetEvents(context.Background(), request)
+	if err != nil {
+		return nil, fmt.Errorf("soroban rpc get events had error %w", err)
+	}
+
+	return response, nil
+}
+
+// AllEvents walks all pages of events matching the request, starting from the
+// request cursor or start ledger, until a page is returned with less than the
+// page limit. Iteration stops after yielding the first error.
+func AllEvents(e2eConfig *E2EConfig, request GetEventsRequest) iter.Seq2[EventInfo, error] {
+	return func(yield func(EventInfo, error) bool) {
+		rpcClient := NewRPCClient(e2eConfig)
+		pagination := PaginationOptions{}
+		if request.Pagination != nil {
+			pagination = *request.Pagination
+		}
+		request.Pagination = &pagination
+
+		for {
+			response, err := rpcClient.GetEvents(context.Background(), request)
+			if err != nil {
+				yield(EventInfo{}, fmt.Errorf("soroban rpc get events had error %w", err))
+				return
+			}
+
+			for _, event := range response.Events {
+				if !yield(event, nil) {
+					return
+				}
+			}
+
+			// rpc applies a default limit when none is requested, so an empty page marks the end
+			if len(response.Events) == 0 || (pagination.Limit > 0 && uint(len(response.Events)) < pagination.Limit) || response.Cursor == "" {
+				return
+			}
+
+			pagination.Cursor = response.Cursor
+			request.StartLedger = 0
+		}
+	}
+}
@@ -38,7 +38,18 @@ async function main() {
       throw new Error(`No events in response: ${JSON.stringify(response)}`);
   }
 
-  console.log(JSON.stringify(response.events));
+  // print events in same shape as rpc returns them, with topic and value as base64 xdr
+  console.log(JSON.stringify(response.events.map((event) => ({
+    type: event.type,
+    ledger: event.ledger,
+    ledgerClosedAt: event.ledgerClosedAt,
+    contractId: event.contractId?.contractId(),
+    id: event.id,
+    inSuccessfulContractCall: event.inSuccessfulContractCall,
+    txHash: event.txHash,
+    topic: event.topic.map((topic) => topic.toXDR('base64')),
+    value: event.value.toXDR('base64'),
+  }))));
 }
 
 main().catch(err => {
@@ -0,0 +1,50 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllEventsWalksAllPages(t *testing.T) {
+	var requests []GetEventsRequest
+	client := newTestRPCServer(t, func(request rpcRequest) interface{} {
+		var getEventsRequest GetEventsRequest
+		params, _ := json.Marshal(request.Params)
+		require.NoError(t, json.Unmarshal(params, &getEventsRequest))
+		requests = append(requests, getEventsRequest)
+
+		// three events in total, served two per page
+		page := len(requests)
+		events := []EventInfo{}
+		for i := (page - 1) * 2; i < 3 && i < page*2; i++ {
+			events = append(events, EventInfo{ID: fmt.Sprint(i)})
+		}
+		return GetEventsResponse{Events: events, Cursor: fmt.Sprint("cursor", page)}
+	})
+
+	request := GetEventsRequest{
+		StartLedger: 10,
+		EndLedger:   20,
+		Filters:     []EventFilter{{EventType: EventTypeContract}},
+		Pagination:  &PaginationOptions{Limit: 2},
+	}
+
+	ids := []string{}
+	for event, err := range AllEvents(&E2EConfig{TargetNetworkRPCURL: client.URL}, request) {
+		require.NoError(t, err)
+		ids = append(ids, event.ID)
+	}
+
+	assert.Equal(t, []string{"0", "1", "2"}, ids)
+	require.Len(t, requests, 2)
+	assert.Equal(t, uint32(10), requests[0].StartLedger)
+	assert.Equal(t, "", requests[0].Pagination.Cursor)
+	assert.Equal(t, uint32(0), requests[1].StartLedger)
+	assert.Equal(t, uint32(20), requests[1].EndLedger)
+	assert.Equal(t, "cursor1", requests[1].Pagination.Cursor)
+	assert.Equal(t, EventTypeContract, requests[1].Filters[0].EventType)
+}
@@ -11,9 +11,49 @@ import (
 	e2e "github.com/stellar/system-test"
 )
 
-// return the fn response as a serialized string
+// returns the deployed contract id
+func deployContractFromCliTool(compiledContractFileName string, contractWorkingDirectory string, contractExamplesSubPath string, installedContractId string, e2eConfig *e2e.E2EConfig) (string, error) {
+	var envCmd *cmd.Cmd
+
+	if installedContractId != "" {
+		envCmd = cmd.NewCmd("stellar",
+			"contract",
+			"deploy",
+			"--quiet",
+			"--wasm-hash", installedContractId,
+			"--rpc-url", e2eConfig.TargetNetworkRPCURL,
+			"--source", e2eConfig.TargetNetworkSecretKey,
+			"--network-passphrase", e2eConfig.TargetNetworkPassPhrase)
+	} else {
+		envCmd = cmd.NewCmd("stellar",
+			"contract",
+			"deploy",
+			"--quiet",
+			"--wasm", compiledWasmPath(compiledContractFileName, contractWorkingDirectory, contractExamplesSubPath),
+			"--rpc-url", e2eConfig.TargetNetworkRPCURL,
+			"--source", e2eConfig.TargetNetworkSecretKey,
+			"--network-passphrase", e2eConfig.TargetNetworkPassPhrase)
+	}
+
+	result, err := e2e.RunCommand(envCmd, e2eConfig)
+
+	if result.ExitCode != 0 || err != nil {
+		return "", fmt.Errorf("stellar cli deployment of example contract %s had error %v, %v, stderr: %v", compiledContractFileName, result.ExitCode, err, result.StderrTail())
+	}
+
+	if len(result.Stdout) < 1 {
+		return "", fmt.Errorf("stellar cli deployment of example contract %s returned no contract id, stderr: %v", compiledContractFileName, result.StderrTail())
+	}
+
+	return result.Stdout[0], nil
+}
+
+// the cli reports the hash of a tx it sends on stderr, i.e. "Transaction hash is <hash>"
+var txHashPattern = regexp.MustCompile(`(?i)transaction hash is "?([0-9a-f]{64})`)
+
+// return the fn response as a serialized string and the hash of the invocation tx
 // uses secret-key and network-passphrase directly on command
-func invokeContractFromCliTool(deployedContractId, contractName, functionName, functionParams string, e2eConfig *e2e.E2EConfig) (string, error) {
+func invokeContractFromCliTool(deployedContractId, contractName, functionName, functionParams string, e2eConfig *e2e.E2EConfig) (string, string, error) {
 	args := []string{
 		"contract",
 		"invoke",
@@ -22,6 +62,7 @@ func invokeContractFromCliTool(deployedContractId, contractName, functionName, f
 		"--source", e2eConfig.TargetNetworkSecretKey,
 		"--network-passphrase", e2eConfig.TargetNetworkPassPhrase,
 		"--send", "yes",
+		"--verbose",
 		"--",
 		functionName,
 	}
@@ -32,53 +73,69 @@ func invokeContractFromCliTool(deployedContractId, contractName, functionName, f
 
 	envCmd := cmd.NewCmd("stellar", args...)
 
-	status, stdOutLines, err := e2e.RunCommand(envCmd, e2eConfig)
-	stdOut := strings.TrimSpace(strings.Join(stdOutLines, "\n"))
+	result, err := e2e.RunCommand(envCmd, e2eConfig)
+	stdOut := strings.TrimSpace(strings.Join(result.Stdout, "\n"))
 
-	if status != 0 || err != nil {
-		return "", fmt.Errorf("stellar cli invoke of example contract %s had error %v, %v, stdout: %v", contractName, status, err, stdOut)
+	if result.ExitCode != 0 || err != nil {
+		return "", "", fmt.Errorf("stellar cli invoke of example contract %s had error %v, %v, stdout: %v, stderr: %v", contractName, result.ExitCode, err, stdOut, result.StderrTail())
 	}
 
 	if stdOut == "" {
-		return "", fmt.Errorf("stellar cli invoke of example contract %s did not emit successful response", contractName)
+		return "", "", fmt.Errorf("stellar cli invoke of example contract %s did not emit successful response, stderr: %v", contractName, result.StderrTail())
 	}
 
-	return stdOut, nil
+	return stdOut, txHashFromOutput(result.Stderr), nil
 }
 
 // invokes the contract using identities and network from prior setup of config state in cli
-func invokeContractFromCliToolWithConfig(deployedContractId, contractName, functionName, parameters, identity, networkConfig string, e2eConfig *e2e.E2EConfig) (string, error) {
+func invokeContractFromCliToolWithConfig(deployedContractId, contractName, functionName, parameters, identity, identityHDIndex, networkConfig string, e2eConfig *e2e.E2EConfig) (string, string, error) {
 	args := []string{
 		"contract",
 		"invoke",
 		"--id", deployedContractId,
 		"--source", identity,
 		"--network", networkConfig,
-		"--",
-		functionName,
+		"--send", "yes",
+		"--verbose",
 	}
 
+	if identityHDIndex != "" {
+		args = append(args, "--hd-path", identityHDIndex)
+	}
+
+	args = append(args, "--", functionName)
+
 	if parameters != "" {
 		args = append(args, strings.Split(parameters, " ")...)
 	}
 
 	envCmd := cmd.NewCmd("stellar", args...)
 
-	status, stdOutLines, err := e2e.RunCommand(envCmd, e2eConfig)
-	stdOut := strings.TrimSpace(strings.Join(stdOutLines, "\n"))
+	result, err := e2e.RunCommand(envCmd, e2eConfig)
+	stdOut := strings.TrimSpace(strings.Join(result.Stdout, "\n"))
 
-	if status != 0 || err != nil {
-		return "", fmt.Errorf("stellar cli invoke of example contract with config states, %s had error %v, %v, stdout: %v", contractName, status, err, stdOut)
+	if result.ExitCode != 0 || err != nil {
+		return "", "", fmt.Errorf("stellar cli invoke of example contract with config states, %s had error %v, %v, stdout: %v, stderr: %v", contractName, result.ExitCode, err, stdOut, result.StderrTail())
 	}
 
 	if stdOut == "" {
-		return "", fmt.Errorf("stellar cli invoke of example contract with config states, %s did not emit successful response", contractName)
+		return "", "", fmt.Errorf("stellar cli invoke of example contract with config states, %s did not emit successful response, stderr: %v", contractName, result.StderrTail())
 	}
 
-	return stdOut, nil
+	return stdOut, txHashFromOutput(result.Stderr), nil
+}
+
+// the hash of the last tx reported in the output, empty if none was reported
+func txHashFromOutput(output []string) string {
+	for i := len(output) - 1; i >= 0; i-- {
+		if match := txHashPattern.FindStringSubmatch(output[i]); match != nil {
+			return strings.ToLower(match[1])
+		}
+	}
+	return ""
 }
 
-func getEventsFromCliTool(ledgerFrom uint32, deployedContractId string, size uint32, e2eConfig *e2e.E2EConfig) ([]map[string]interface{}, error) {
+func getEventsFromCliTool(ledgerFrom uint32, deployedContractId string, size uint32, e2eConfig *e2e.E2EConfig) ([]e2e.ContractEvent, error) {
 
 	args := []string{
 		"events",
@@ -92,23 +149,64 @@ func getEventsFromCliTool(ledgerFrom uint32, deployedContractId string, size uin
 
 	envCmd := cmd.NewCmd("stellar", args...)
 
-	status, stdOutLines, err := e2e.RunCommand(envCmd, e2eConfig)
-	var jsonEvents []map[string]interface{}
+	result, err := e2e.RunCommand(envCmd, e2eConfig)
 
-	if status != 0 || err != nil {
-		return jsonEvents, fmt.Errorf("stellar cli get events had error %v, %v", status, err)
+	if result.ExitCode != 0 || err != nil {
+		return nil, fmt.Errorf("stellar cli get events had error %v, %v, stderr: %v", result.ExitCode, err, result.StderrTail())
 	}
 
-	// put commas between any json event objects if more than one found
-	regex := regexp.MustCompile(`\n}\n{\n`) // Create a regex pattern
-	stdOutEventsValidJson := regex.ReplaceAllString(strings.Join(stdOutLines, "\n"), "\n}\n,\n{\n")
-	// wrap the json objects in json array brackets
-	stdOutEventsValidJson = "[" + stdOutEventsValidJson + "]"
+	jsonEvents, err := decodeEventsJson(strings.Join(result.Stdout, "\n"))
+	if err != nil {
+		return nil, fmt.Errorf("stellar cli get events console output was not parseable as event json, %v, stderr: %v", err, result.StderrTail())
+	}
 
-	err = json.Unmarshal([]byte(stdOutEventsValidJson), &jsonEvents)
+	contractEvents, err := e2e.ContractEventsFromEventInfos(jsonEvents)
 	if err != nil {
-		return jsonEvents, fmt.Errorf("stellar cli get events console output %v was not parseable as event json, %e", strings.Join(stdOutLines, "\n"), err)
+		return nil, fmt.Errorf("stellar cli get events console output had invalid event, %v", err)
 	}
 
-	return jsonEvents, nil
+	return contractEvents, nil
+}
+
+// decodes json event objects from console output in any whitespace layout, i.e. one
+// object per line or pretty printed objects one after another, or a json array of objects
+func decodeEventsJson(output string) ([]e2e.EventInfo, error) {
+	events := []e2e.EventInfo{}
+	decoder := json.NewDecoder(strings.NewReader(output))
+
+	isArray := strings.HasPrefix(strings.TrimSpace(output), "[")
+	if isArray {
+		if _, err := decoder.Token(); err != nil {
+			return nil, fmt.Errorf("not able to read start of event array, %v", err)
+		}
+	}
+
+	for decoder.More() {
+		offset := decoder.InputOffset()
+		var event e2e.EventInfo
+		if err := decoder.Decode(&event); err != nil {
+			return nil, fmt.Errorf("event object %v at offset %v had error %v, output from there: %v", len(events)+1, offset, err, truncate(output[offset:], 200))
+		}
+		events = append(events, event)
+	}
+
+	if isArray {
+		if _, err := decoder.Token(); err != nil {
+			return nil, fmt.Errorf("not able to read end of event array after %v events, %v", len(events), err)
+		}
+	}
+
+	// the decoder may not have read all of the output yet, so check what follows its offset in the output itself
+	if trailing := strings.TrimSpace(output[decoder.InputOffset():]); trailing != "" {
+		return nil, fmt.Errorf("unexpected output after %v events, %v", len(events), truncate(trailing, 200))
+	}
+
+	return events, nil
+}
+
+func truncate(value string, length int) string {
+	if len(value) <= length {
+		return value
+	}
+	return value[:length] + "..."
 }
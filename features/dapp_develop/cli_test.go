@@ -0,0 +1,52 @@
+package dapp_develop
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeEventsJson(t *testing.T) {
+	for name, output := range map[string]string{
+		"ndjson":    "{\"id\":\"1\",\"type\":\"contract\"}\n{\"id\":\"2\",\"type\":\"contract\"}",
+		"pretty":    "{\n  \"id\": \"1\",\n  \"type\": \"contract\"\n}\n{\n  \"id\": \"2\",\n  \"type\": \"contract\"\n}\n",
+		"same line": "{\"id\":\"1\",\"type\":\"contract\"} {\"id\":\"2\",\"type\":\"contract\"}",
+		"array":     "[\n{\"id\":\"1\",\"type\":\"contract\"},\n{\"id\":\"2\",\"type\":\"contract\"}\n]",
+	} {
+		t.Run(name, func(t *testing.T) {
+			events, err := decodeEventsJson(output)
+			require.NoError(t, err)
+			require.Len(t, events, 2)
+			assert.Equal(t, "1", events[0].ID)
+			assert.Equal(t, "2", events[1].ID)
+		})
+	}
+
+	events, err := decodeEventsJson("\n")
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	_, err = decodeEventsJson("{\"id\":\"1\"}\n{\"id\":2}")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "event object 2")
+
+	// trailing text after an array, including past what the decoder has buffered
+	for _, padding := range []string{"\n", strings.Repeat(" ", 8192)} {
+		_, err = decodeEventsJson("[{\"id\":\"1\"}]" + padding + "trailing text")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unexpected output after 1 events, trailing text")
+	}
+}
+
+func TestTxHashFromOutput(t *testing.T) {
+	hash := "0c5d6f0fb9a6b3c5e2a5f0d1c7e4b8a9f3d2c1b0a9e8d7c6b5a4f3e2d1c0b9a8"
+	assert.Equal(t, hash, txHashFromOutput([]string{
+		"ℹ️ Simulating transaction…",
+		"ℹ️ Transaction hash is " + strings.ToUpper(hash),
+		"🔗 https://stellar.expert/explorer/local/tx/" + hash,
+	}))
+	assert.Equal(t, hash, txHashFromOutput([]string{`INFO soroban_cli::rpc: Transaction hash is "` + hash + `"`}))
+	assert.Equal(t, "", txHashFromOutput([]string{"ℹ️ Simulation identified as read-only. Send by rerunning with `--send=yes`."}))
+}
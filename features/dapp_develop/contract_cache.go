@@ -0,0 +1,251 @@
+package dapp_develop
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-cmd/cmd"
+
+	e2e "github.com/stellar/system-test"
+)
+
+// relative to an example contract's directory, where `stellar contract build` puts the wasm
+const compiledContractTargetPath = "target/wasm32v1-none/release"
+
+// caches example repo clones and compiled contract wasm for the duration of a test run,
+// so each repo is cloned once and each example contract is built once across all scenarios.
+// compiled wasm is keyed on repo url, resolved commit hash and example sub path.
+// contracts may instead come from a local source directory or a directory of prebuilt wasm.
+type contractCache struct {
+	mu  sync.Mutex
+	dir string
+	// repo url + git ref to local clone dir and its resolved commit hash
+	clones map[string]clonedRepo
+	// content address key to dir holding the compiled wasm files
+	builds map[string]string
+}
+
+type clonedRepo struct {
+	url    string
+	dir    string
+	commit string
+}
+
+var examplesCache = &contractCache{}
+
+// removes all cached clones and wasm, the next use starts a new cache
+func (c *contractCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dir := c.dir
+	c.dir, c.clones, c.builds = "", nil, nil
+	if dir == "" {
+		return nil
+	}
+	return os.RemoveAll(dir)
+}
+
+// places the compiled wasm of the example contract into
+// contractWorkingDirectory/contractExamplesSubPath/target/wasm32v1-none/release,
+// cloning and building only if not already cached in this run
+func (c *contractCache) compiledContract(contractExamplesSubPath string, contractWorkingDirectory string, e2eConfig *e2e.E2EConfig) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.init(); err != nil {
+		return err
+	}
+
+	targetDir := filepath.Join(contractWorkingDirectory, contractExamplesSubPath, compiledContractTargetPath)
+
+	if e2eConfig.ContractsWasmPath != "" {
+		return copyWasmFiles(prebuiltWasmDir(e2eConfig.ContractsWasmPath, contractExamplesSubPath), targetDir)
+	}
+
+	repo, err := c.source(e2eConfig)
+	if err != nil {
+		return err
+	}
+
+	key := contractCacheKey(repo.url, repo.commit, contractExamplesSubPath)
+	buildDir, built := c.builds[key]
+	if !built || e2eConfig.ForceContractRebuild {
+		if buildDir, err = c.build(repo, key, contractExamplesSubPath, e2eConfig); err != nil {
+			return err
+		}
+		c.builds[key] = buildDir
+	} else if e2eConfig.VerboseOutput {
+		fmt.Printf("using cached build of example contract %s at commit %s\n\n", contractExamplesSubPath, repo.commit)
+	}
+
+	return copyWasmFiles(buildDir, targetDir)
+}
+
+// the contracts source to build from, a local directory if configured, otherwise the examples git repo
+func (c *contractCache) source(e2eConfig *e2e.E2EConfig) (clonedRepo, error) {
+	if e2eConfig.ContractsSourcePath == "" {
+		return c.clone(e2eConfig)
+	}
+
+	sourceDir, err := filepath.Abs(e2eConfig.ContractsSourcePath)
+	if err != nil {
+		return clonedRepo{}, fmt.Errorf("invalid contracts source path %s, %v", e2eConfig.ContractsSourcePath, err)
+	}
+	if info, err := os.Stat(sourceDir); err != nil || !info.IsDir() {
+		return clonedRepo{}, fmt.Errorf("contracts source path %s is not a directory, %v", sourceDir, err)
+	}
+
+	// local sources are not versioned, builds are cached by path for the run
+	return clonedRepo{url: sourceDir, dir: sourceDir, commit: "local"}, nil
+}
+
+// prebuilt wasm may be laid out per example sub path, or all in the one directory
+func prebuiltWasmDir(wasmPath string, contractExamplesSubPath string) string {
+	subPathDir := filepath.Join(wasmPath, contractExamplesSubPath)
+	if info, err := os.Stat(subPathDir); err == nil && info.IsDir() {
+		return subPathDir
+	}
+	return wasmPath
+}
+
+func (c *contractCache) init() error {
+	if c.dir != "" {
+		return nil
+	}
+
+	dir, err := os.MkdirTemp("", "soroban_examples_cache_*")
+	if err != nil {
+		return fmt.Errorf("could not create example contracts cache directory, %v", err)
+	}
+
+	c.dir = dir
+	c.clones = map[string]clonedRepo{}
+	c.builds = map[string]string{}
+	return nil
+}
+
+func (c *contractCache) clone(e2eConfig *e2e.E2EConfig) (clonedRepo, error) {
+	cloneKey := e2eConfig.SorobanExamplesRepoURL + "\n" + e2eConfig.SorobanExamplesGitHash
+	if repo, found := c.clones[cloneKey]; found {
+		return repo, nil
+	}
+
+	repoDir := filepath.Join(c.dir, "repos", hashKey(cloneKey))
+	envCmd := cmd.NewCmd("git", "clone", e2eConfig.SorobanExamplesRepoURL, repoDir)
+
+	result, err := e2e.RunCommandContext(context.Background(), envCmd, e2eConfig, e2eConfig.BuildCommandTimeout)
+
+	if result.ExitCode != 0 || err != nil {
+		return clonedRepo{}, fmt.Errorf("git clone of soroban example contracts from %s had error %v, %v, stderr: %v", e2eConfig.SorobanExamplesRepoURL, result.ExitCode, err, result.StderrTail())
+	}
+
+	envCmd = cmd.NewCmd("git", "checkout", e2eConfig.SorobanExamplesGitHash)
+	envCmd.Dir = repoDir
+
+	result, err = e2e.RunCommand(envCmd, e2eConfig)
+
+	if result.ExitCode != 0 || err != nil {
+		return clonedRepo{}, fmt.Errorf("git checkout %v of sample contracts repo %s had error %v, %v, stderr: %v", e2eConfig.SorobanExamplesGitHash, e2eConfig.SorobanExamplesRepoURL, result.ExitCode, err, result.StderrTail())
+	}
+
+	envCmd = cmd.NewCmd("git", "rev-parse", "HEAD")
+	envCmd.Dir = repoDir
+
+	result, err = e2e.RunCommand(envCmd, e2eConfig)
+
+	if result.ExitCode != 0 || err != nil || len(result.Stdout) < 1 {
+		return clonedRepo{}, fmt.Errorf("git rev-parse of sample contracts repo %s had error %v, %v, stderr: %v", e2eConfig.SorobanExamplesRepoURL, result.ExitCode, err, result.StderrTail())
+	}
+
+	repo := clonedRepo{url: e2eConfig.SorobanExamplesRepoURL, dir: repoDir, commit: strings.TrimSpace(result.Stdout[0])}
+	c.clones[cloneKey] = repo
+	return repo, nil
+}
+
+func (c *contractCache) build(repo clonedRepo, key string, contractExamplesSubPath string, e2eConfig *e2e.E2EConfig) (string, error) {
+	envCmd := cmd.NewCmd("stellar", "contract", "build")
+	envCmd.Dir = filepath.Join(repo.dir, contractExamplesSubPath)
+
+	result, err := e2e.RunCommandContext(context.Background(), envCmd, e2eConfig, e2eConfig.BuildCommandTimeout)
+
+	if result.ExitCode != 0 || err != nil {
+		return "", fmt.Errorf("cargo build of sample contract %v/%v had error %v, %v, stderr: %v", repo.url, contractExamplesSubPath, result.ExitCode, err, result.StderrTail())
+	}
+
+	buildDir := filepath.Join(c.dir, "wasm", key)
+	if err := os.RemoveAll(buildDir); err != nil {
+		return "", fmt.Errorf("could not clear cached wasm of sample contract %v, %v", contractExamplesSubPath, err)
+	}
+
+	// contracts in a cargo workspace are built into the workspace root target dir
+	targetDir := filepath.Join(envCmd.Dir, compiledContractTargetPath)
+	if wasmFiles, _ := filepath.Glob(filepath.Join(targetDir, "*.wasm")); len(wasmFiles) == 0 {
+		targetDir = filepath.Join(repo.dir, compiledContractTargetPath)
+	}
+
+	if err := copyWasmFiles(targetDir, buildDir); err != nil {
+		return "", err
+	}
+
+	return buildDir, nil
+}
+
+func contractCacheKey(repoURL string, commit string, contractExamplesSubPath string) string {
+	return hashKey(strings.Join([]string{repoURL, commit, filepath.Clean(contractExamplesSubPath)}, "\n"))
+}
+
+func hashKey(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// copies the *.wasm files found directly in fromDir into toDir, creating toDir if needed
+func copyWasmFiles(fromDir string, toDir string) error {
+	wasmFiles, err := filepath.Glob(filepath.Join(fromDir, "*.wasm"))
+	if err != nil {
+		return fmt.Errorf("could not list compiled wasm in %s, %v", fromDir, err)
+	}
+	if len(wasmFiles) == 0 {
+		return fmt.Errorf("no compiled wasm found in %s", fromDir)
+	}
+
+	if err := os.MkdirAll(toDir, 0755); err != nil {
+		return fmt.Errorf("could not create directory %s, %v", toDir, err)
+	}
+
+	for _, wasmFile := range wasmFiles {
+		if err := copyFile(wasmFile, filepath.Join(toDir, filepath.Base(wasmFile))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyFile(from string, to string) error {
+	source, err := os.Open(from)
+	if err != nil {
+		return fmt.Errorf("could not open %s, %v", from, err)
+	}
+	defer source.Close()
+
+	target, err := os.Create(to)
+	if err != nil {
+		return fmt.Errorf("could not create %s, %v", to, err)
+	}
+
+	if _, err = io.Copy(target, source); err != nil {
+		target.Close()
+		return fmt.Errorf("could not copy %s to %s, %v", from, to, err)
+	}
+
+	return target.Close()
+}
@@ -0,0 +1,41 @@
+package dapp_develop
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContractCacheKey(t *testing.T) {
+	key := contractCacheKey("https://github.com/stellar/soroban-examples.git", "abc123", "hello_world")
+	assert.Equal(t, key, contractCacheKey("https://github.com/stellar/soroban-examples.git", "abc123", "hello_world/"))
+	assert.NotEqual(t, key, contractCacheKey("https://github.com/stellar/soroban-examples.git", "def456", "hello_world"))
+	assert.NotEqual(t, key, contractCacheKey("https://github.com/stellar/soroban-examples.git", "abc123", "events"))
+}
+
+func TestCopyWasmFiles(t *testing.T) {
+	fromDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(fromDir, "soroban_hello_world_contract.wasm"), []byte("wasm"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(fromDir, "soroban_hello_world_contract.d"), []byte("deps"), 0644))
+
+	toDir := filepath.Join(t.TempDir(), "hello_world", compiledContractTargetPath)
+	require.NoError(t, copyWasmFiles(fromDir, toDir))
+
+	copied, err := os.ReadFile(filepath.Join(toDir, "soroban_hello_world_contract.wasm"))
+	require.NoError(t, err)
+	assert.Equal(t, "wasm", string(copied))
+	assert.NoFileExists(t, filepath.Join(toDir, "soroban_hello_world_contract.d"))
+
+	assert.Error(t, copyWasmFiles(t.TempDir(), toDir))
+}
+
+func TestPrebuiltWasmDir(t *testing.T) {
+	wasmPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(wasmPath, "events"), 0755))
+
+	assert.Equal(t, filepath.Join(wasmPath, "events"), prebuiltWasmDir(wasmPath, "events"))
+	assert.Equal(t, wasmPath, prebuiltWasmDir(wasmPath, "hello_world"))
+}
@@ -11,11 +11,13 @@ Scenario Outline: DApp developer compiles, installs, deploys and invokes a contr
   Then The result should be <Result>
 
   Examples: 
-        | Tool         | ContractExampleSubPath | ContractName                  | ContractCompiledFileName             | FunctionName | FunctionParams | Result             |
-        | NODEJS       | hello_world            | soroban-hello-world-contract  | soroban_hello_world_contract.wasm    | hello        | to:Aloha       | ["Hello","Aloha"]  |
-        | CLI          | hello_world            | soroban-hello-world-contract  | soroban_hello_world_contract.wasm    | hello        | --to=Aloha     | ["Hello","Aloha"]  |
-        | NODEJS       | increment              | soroban-increment-contract    | soroban_increment_contract.wasm      | increment    |                | 1                  |
-        | CLI          | increment              | soroban-increment-contract    | soroban_increment_contract.wasm      | increment    |                | 1                  |
+        | Tool         | ContractExampleSubPath | ContractName                  | ContractCompiledFileName             | FunctionName | FunctionParams  | Result             |
+        | NODEJS       | hello_world            | soroban-hello-world-contract  | soroban_hello_world_contract.wasm    | hello        | to:Aloha        | ["Hello","Aloha"]  |
+        | CLI          | hello_world            | soroban-hello-world-contract  | soroban_hello_world_contract.wasm    | hello        | --to=Aloha      | ["Hello","Aloha"]  |
+        | NODEJS       | increment              | soroban-increment-contract    | soroban_increment_contract.wasm      | increment    |                 | 1                  |
+        | CLI          | increment              | soroban-increment-contract    | soroban_increment_contract.wasm      | increment    |                 | 1                  |
+        | GO           | hello_world            | soroban-hello-world-contract  | soroban_hello_world_contract.wasm    | hello        | to:string:Aloha | ["Hello","Aloha"]  |
+        | GO           | increment              | soroban-increment-contract    | soroban_increment_contract.wasm      | increment    |                 | 1                  |
 
 
 Scenario Outline: DApp developer compiles, deploys and invokes a contract
@@ -29,11 +31,64 @@ Scenario Outline: DApp developer compiles, deploys and invokes a contract
   And The result should be to receive <EventCount> contract events for <ContractName> from <Tool>
 
   Examples: 
-        | Tool         | ContractExampleSubPath | ContractName                  | ContractCompiledFileName             | FunctionName | FunctionParams | Result             | EventCount |
-        | NODEJS       | hello_world            | soroban-hello-world-contract  | soroban_hello_world_contract.wasm    | hello        | to:Aloha       | ["Hello","Aloha"]  | 0          |
-        | CLI          | hello_world            | soroban-hello-world-contract  | soroban_hello_world_contract.wasm    | hello        | --to=Aloha     | ["Hello","Aloha"]  | 0          |
-        | NODEJS       | increment              | soroban-increment-contract    | soroban_increment_contract.wasm      | increment    |                | 1                  | 0          |
-        | CLI          | increment              | soroban-increment-contract    | soroban_increment_contract.wasm      | increment    |                | 1                  | 0          |
+        | Tool         | ContractExampleSubPath | ContractName                  | ContractCompiledFileName             | FunctionName | FunctionParams  | Result             | EventCount |
+        | NODEJS       | hello_world            | soroban-hello-world-contract  | soroban_hello_world_contract.wasm    | hello        | to:Aloha        | ["Hello","Aloha"]  | 0          |
+        | CLI          | hello_world            | soroban-hello-world-contract  | soroban_hello_world_contract.wasm    | hello        | --to=Aloha      | ["Hello","Aloha"]  | 0          |
+        | NODEJS       | increment              | soroban-increment-contract    | soroban_increment_contract.wasm      | increment    |                 | 1                  | 0          |
+        | CLI          | increment              | soroban-increment-contract    | soroban_increment_contract.wasm      | increment    |                 | 1                  | 0          |
+        | GO           | hello_world            | soroban-hello-world-contract  | soroban_hello_world_contract.wasm    | hello        | to:string:Aloha | ["Hello","Aloha"]  | 0          |
+        | GO           | increment              | soroban-increment-contract    | soroban_increment_contract.wasm      | increment    |                 | 1                  | 0          |
+
+
+Scenario Outline: DApp developer compiles, deploys and invokes a contract using the same tool
+  Given I used cargo to compile example contract <ContractExampleSubPath>
+  And I used rpc to verify my account is on the network
+  And I deploy contract <ContractExampleSubPath> / <ContractCompiledFileName> from tool <Tool> using my secret key
+  When I invoke function <FunctionName> on <ContractName> with request parameters <FunctionParams> from tool <Tool> using my secret key
+  Then The result should be <Result>
+
+  Examples: 
+        | Tool         | ContractExampleSubPath | ContractName                  | ContractCompiledFileName             | FunctionName | FunctionParams  | Result             |
+        | CLI          | hello_world            | soroban-hello-world-contract  | soroban_hello_world_contract.wasm    | hello        | --to=Aloha      | ["Hello","Aloha"]  |
+        | GO           | hello_world            | soroban-hello-world-contract  | soroban_hello_world_contract.wasm    | hello        | to:string:Aloha | ["Hello","Aloha"]  |
+        | CLI          | increment              | soroban-increment-contract    | soroban_increment_contract.wasm      | increment    |                 | 1                  |
+        | GO           | increment              | soroban-increment-contract    | soroban_increment_contract.wasm      | increment    |                 | 1                  |
+
+
+Scenario Outline: DApp developer deploys and invokes a contract with Go sdk transactions confirmed within a ledger limit
+  Given I used cargo to compile example contract <ContractExampleSubPath>
+  And I used rpc to verify my account is on the network
+  And I expect transactions submitted from tool <Tool> to be confirmed within 10 ledgers
+  And I deploy contract <ContractExampleSubPath> / <ContractCompiledFileName> from tool <Tool> using my secret key
+  When I invoke function <FunctionName> on <ContractName> with request parameters <FunctionParams> from tool <Tool> using my secret key
+  Then The result should be <Result>
+
+  Examples: 
+        | Tool         | ContractExampleSubPath | ContractName                  | ContractCompiledFileName             | FunctionName | FunctionParams  | Result             |
+        | GO           | hello_world            | soroban-hello-world-contract  | soroban_hello_world_contract.wasm    | hello        | to:string:Aloha | ["Hello","Aloha"]  |
+        | GO           | increment              | soroban-increment-contract    | soroban_increment_contract.wasm      | increment    |                 | 1                  |
+
+
+Scenario Outline: DApp developer invokes a contract and verifies the events it emitted
+  Given I used cargo to compile example contract <ContractExampleSubPath>
+  And I used rpc to verify my account is on the network
+  And I used rpc to get network latest ledger
+  And I used cli to deploy contract <ContractExampleSubPath> / <ContractCompiledFileName> using my secret key
+  When I invoke function <FunctionName> on <ContractName> with request parameters <FunctionParams> from tool <Tool> using my secret key
+  Then The result should be <Result>
+  And The invocation should have emitted <EventCount> contract events
+  And The invocation contract event 1 should have topics <EventTopics> and data <EventData>
+  And The result should be to receive <EventCount> contract events for <ContractName> from <Tool>
+  And Paging through contract events for <ContractName> 1 at a time should yield <EventCount> contract events
+  And The contract events for <ContractName> from <Tool> should include
+    | Topic         | Value       |
+    | <EventTopics> | <EventData> |
+
+  Examples: 
+        | Tool         | ContractExampleSubPath | ContractName                  | ContractCompiledFileName             | FunctionName | FunctionParams  | Result | EventCount | EventTopics        | EventData |
+        | NODEJS       | events                 | soroban-events-contract       | soroban_events_contract.wasm         | increment    |                 | 1      | 1          | COUNTER,increment  | 1         |
+        | CLI          | events                 | soroban-events-contract       | soroban_events_contract.wasm         | increment    |                 | 1      | 1          | COUNTER,increment  | 1         |
+        | GO           | events                 | soroban-events-contract       | soroban_events_contract.wasm         | increment    |                 | 1      | 1          | COUNTER,increment  | 1         |
 
 
 Scenario Outline: DApp developer uses config states, compiles, deploys and invokes contract with authorizations
@@ -51,3 +106,61 @@ Scenario Outline: DApp developer uses config states, compiles, deploys and invok
         | Tool         | ContractExampleSubPath | ContractName                  | ContractCompiledFileName      | FunctionName     | FunctionParams                              | RootIdentityName  | TesterIdentityName  | NetworkConfigName   | Result |
 #       | NODEJS       | auth                   | soroban-auth-contract         | soroban_auth_contract.wasm    | increment        | --user <tester_identity_pub_key> --value 2  | r1                | t1                  | standalone          | 2      |
         | CLI          | auth                   | soroban-auth-contract         | soroban_auth_contract.wasm    | increment        | --user <tester_identity_pub_key> --value 2  | r1                | t1                  | standalone          | 2      |
+
+
+Scenario: DApp developer manages identities with the cli
+  Given I used rpc to verify my account is on the network
+  And I used cli to add Identity r1 for my secret key
+  And I used cli to generate Identity g1
+  When I used cli to fund Identity g1 from friendbot
+  Then The cli keys for Identity g1 should match an independently derived keypair
+  And The cli keys for Identity r1 should match an independently derived keypair
+  And The cli should list Identities r1, g1
+  When I used cli to remove Identity g1
+  Then The cli should not list Identities g1
+  And The cli should list Identities r1
+
+
+Scenario Outline: DApp developer uses seed phrase identities derived at HD indexes to invoke contract with authorizations
+  Given I used cargo to compile example contract <ContractExampleSubPath>
+  And I used rpc to verify my account is on the network
+  And I used cli to add Network Config <NetworkConfigName> for rpc and standalone
+  And I used cli to add Identity <RootIdentityName> for my secret key
+  And I used cli to add Identity <SeedIdentityName> for a new seed phrase at HD index <HDIndex>
+  And The cli keys for Identity <SeedIdentityName> should match the SEP-0005 derivation of its seed phrase
+  And I used rpc to submit transaction to create the account of Identity <SeedIdentityName> on the network
+  And I used cli to deploy contract <ContractExampleSubPath> / <ContractCompiledFileName> using Identity <RootIdentityName> and Network Config <NetworkConfigName>
+  When I invoke function <FunctionName> on <ContractName> with request parameters <FunctionParams> from tool <Tool> using Identity <SeedIdentityName> as invoker and Network Config <NetworkConfigName>
+  Then The result should be <Result>
+
+  Examples: 
+        | Tool         | ContractExampleSubPath | ContractName                  | ContractCompiledFileName      | FunctionName     | FunctionParams                              | RootIdentityName  | SeedIdentityName  | HDIndex | NetworkConfigName   | Result |
+        | CLI          | auth                   | soroban-auth-contract         | soroban_auth_contract.wasm    | increment        | --user <tester_identity_pub_key> --value 2  | r1                | s1                | 0       | standalone          | 2      |
+        | CLI          | auth                   | soroban-auth-contract         | soroban_auth_contract.wasm    | increment        | --user <tester_identity_pub_key> --value 2  | r1                | s1                | 3       | standalone          | 2      |
+
+
+Scenario Outline: DApp developer manages network configs and the cli resolves the network from config, env vars and flags
+  Given I used cli to add Network Config <NetworkConfigName> for rpc and standalone
+  And I used cli to add Network Config other for rpc and passphrase Other Network ; October 2026
+  And The cli network config <NetworkConfigName> should be saved with its rpc url and passphrase
+  And The cli network config other should be saved with its rpc url and passphrase
+  And The cli should list Network Configs <NetworkConfigName>, other
+  When I used cli to resolve the network with flags <Flags> and env vars <EnvVars>
+  Then The cli should have resolved Network Config <ResolvedNetworkConfig>
+  When I used cli to remove Network Config other
+  Then The cli should not list Network Configs other
+  And The cli should list Network Configs <NetworkConfigName>
+
+  Examples: 
+        | NetworkConfigName | Flags                                                                          | EnvVars                                                                      | ResolvedNetworkConfig |
+        | standalone        | --network standalone                                                           |                                                                              | standalone            |
+        | standalone        |                                                                                | STELLAR_NETWORK=other                                                        | other                 |
+        | standalone        | --network standalone                                                           | STELLAR_NETWORK=other                                                        | standalone            |
+        | standalone        | --network other --rpc-url <rpc_url> --network-passphrase <network_passphrase>  |                                                                              | standalone            |
+        | standalone        |                                                                                | STELLAR_RPC_URL=<rpc_url>,STELLAR_NETWORK_PASSPHRASE=<network_passphrase>    | standalone            |
+
+
+Scenario: DApp developer is stopped from mixing a named network config with a partial rpc url override
+  Given I used cli to add Network Config other for rpc and passphrase Other Network ; October 2026
+  When I used cli to resolve the network with flags --network other --rpc-url <rpc_url> and env vars
+  Then The cli should have rejected the network flags for option --network-passphrase
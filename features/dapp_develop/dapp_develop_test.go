@@ -2,15 +2,17 @@ package dapp_develop
 
 import (
 	"context"
+	"encoding/json"
 	"strings"
 
 	"fmt"
 
 	"os"
+	"path/filepath"
+	"regexp"
 	"testing"
 
 	"github.com/cucumber/godog/colors"
-	"github.com/go-cmd/cmd"
 
 	"github.com/cucumber/godog"
 
@@ -38,9 +40,28 @@ type testConfig struct {
 	TesterAccountPublicKey   string
 	TesterAccountPrivateKey  string
 	Identities               map[string]string
-	ContractEvents           []xdr.DiagnosticEvent
-	DiagnosticEvents         []xdr.DiagnosticEvent
 	InitialNetworkState      e2e.LatestLedgerResult
+
+	// seed phrase identities, with the hd index each one signs with
+	SeedPhrases       map[string]string
+	IdentityHDIndexes map[string]uint32
+
+	// network configs added to the cli, by name, with their passphrase
+	NetworkConfigs map[string]string
+	// the outcome of the most recent cli network resolution
+	ResolvedNetworkContractId string
+	ResolvedNetworkStderr     string
+	ResolvedNetworkErr        error
+
+	// the pool account the scenario signs with as its TargetNetworkSecretKey, if the pool is enabled
+	LeasedAccount *keypair.Full
+
+	// the tx hash the tool reported for the most recent contract invocation, the tx and its events
+	// are only fetched from rpc once a step asserts on them
+	InvokeTxHash      string
+	InvokeTransaction *e2e.TransactionStatusResponse
+	ContractEvents    []xdr.ContractEvent
+	DiagnosticEvents  []xdr.DiagnosticEvent
 }
 
 func TestDappDevelop(t *testing.T) {
@@ -49,11 +70,28 @@ func TestDappDevelop(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to setup environment for soroban dapp e2e tests, %v", err)
 	}
+	defer examplesCache.Close()
+
+	if e2eConfig.AccountPoolSize > 0 {
+		if e2eConfig.AccountPool, err = e2e.NewAccountPool(e2eConfig, e2eConfig.AccountPoolSize, e2eConfig.AccountPoolBalance); err != nil {
+			t.Fatalf("Failed to create account pool for soroban dapp e2e tests, %v", err)
+		}
+		// the pool accounts' balances go back to the root account
+		defer func() {
+			if err := e2eConfig.AccountPool.Close(e2eConfig); err != nil {
+				t.Errorf("Failed to close account pool for soroban dapp e2e tests, %v", err)
+			}
+		}()
+	}
+
+	// secrets are redacted from all test report output
+	output := e2e.NewRedactingWriter(colors.Colored(os.Stdout))
+	defer output.Flush()
 
 	opts := &godog.Options{
 		Format:         "pretty",
 		Paths:          []string{e2eConfig.FeaturePath + "/dapp_develop.feature"},
-		Output:         colors.Colored(os.Stdout),
+		Output:         output,
 		StopOnFailure:  true,
 		TestingT:       t,
 		DefaultContext: context.WithValue(context.Background(), e2e.TestConfigContextKey, e2eConfig),
@@ -78,11 +116,15 @@ func compileContractStep(ctx context.Context, contractExamplesSubPath string) er
 }
 
 func deployContractStep(ctx context.Context, contractExamplesSubPath string, compiledContractFileName string) error {
+	return deployContractFromToolStep(ctx, contractExamplesSubPath, compiledContractFileName, "CLI")
+}
+
+func deployContractFromToolStep(ctx context.Context, contractExamplesSubPath string, compiledContractFileName string, tool string) error {
 	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
 	contractWorkingDirectory := fmt.Sprintf("%s/soroban_examples", testConfig.TestWorkingDir)
 
 	var err error
-	if testConfig.DeployedContractId, err = deployContract(compiledContractFileName, contractWorkingDirectory, contractExamplesSubPath, testConfig.InstalledContractId, testConfig.E2EConfig); err != nil {
+	if testConfig.DeployedContractId, err = deployContract(compiledContractFileName, contractWorkingDirectory, contractExamplesSubPath, testConfig.InstalledContractId, tool, testConfig.E2EConfig); err != nil {
 		return err
 	}
 
@@ -126,26 +168,202 @@ func invokeContractStepWithConfig(ctx context.Context, functionName string, cont
 	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
 	var err error
 
+	// the events of a prior invocation no longer apply
+	testConfig.InvokeTxHash = ""
+	testConfig.InvokeTransaction = nil
+	testConfig.ContractEvents = nil
+	testConfig.DiagnosticEvents = nil
+
 	if identity != "" {
 		invokerPubKey, has := testConfig.Identities[identity]
 		if !has {
 			return fmt.Errorf("invocation of contract with config could not proceed, no public key for identity config name %v", identity)
 		}
 		parameters = strings.Replace(parameters, "<tester_identity_pub_key>", invokerPubKey, 1)
-		testConfig.ContractFunctionResponse, err = invokeContractWithConfig(testConfig.DeployedContractId, contractName, functionName, parameters, tool, identity, networkConfig, testConfig.E2EConfig)
+		testConfig.ContractFunctionResponse, testConfig.InvokeTxHash, err = invokeContractWithConfig(testConfig.DeployedContractId, contractName, functionName, parameters, tool, identity, testConfig.identityHDIndex(identity), networkConfig, testConfig.E2EConfig)
 
 	} else {
-		testConfig.ContractFunctionResponse, err = invokeContract(testConfig.DeployedContractId, contractName, functionName, parameters, tool, testConfig.E2EConfig)
+		testConfig.ContractFunctionResponse, testConfig.InvokeTxHash, err = invokeContract(testConfig.DeployedContractId, contractName, functionName, parameters, tool, testConfig.E2EConfig)
 	}
 
 	return err
 }
 
+// fetches the most recent invocation tx from rpc by the hash the tool reported, on first use,
+// and stores the events decoded from its meta on test config
+func (c *testConfig) captureInvocationEvents() error {
+	if c.InvokeTransaction != nil {
+		return nil
+	}
+	if c.InvokeTxHash == "" {
+		return fmt.Errorf("the most recent contract invocation did not report its tx hash, unable to get its events")
+	}
+
+	tx, err := e2e.QueryTxStatus(c.E2EConfig, c.InvokeTxHash)
+	if err != nil {
+		return fmt.Errorf("unable to get invocation tx %v, %w", c.InvokeTxHash, err)
+	}
+	if tx.Status != e2e.TX_SUCCESS {
+		return fmt.Errorf("invocation tx %v has status %v", c.InvokeTxHash, tx.Status)
+	}
+
+	if c.ContractEvents, err = tx.ContractEvents(); err != nil {
+		return fmt.Errorf("unable to get contract events of invocation tx %v, %w", c.InvokeTxHash, err)
+	}
+	if c.DiagnosticEvents, err = tx.DiagnosticEvents(); err != nil {
+		return fmt.Errorf("unable to get diagnostic events of invocation tx %v, %w", c.InvokeTxHash, err)
+	}
+
+	c.InvokeTransaction = tx
+	return nil
+}
+
 func createNetworkConfigStep(ctx context.Context, configName string) error {
 
 	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
 
-	return createNetworkConfig(configName, testConfig.E2EConfig.TargetNetworkRPCURL, testConfig.E2EConfig.TargetNetworkPassPhrase, testConfig.E2EConfig)
+	return createNetworkConfigWithPassphraseStep(ctx, configName, testConfig.E2EConfig.TargetNetworkPassPhrase)
+}
+
+// the config uses the rpc of the network under test, with any passphrase
+func createNetworkConfigWithPassphraseStep(ctx context.Context, configName string, networkPassphrase string) error {
+
+	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+
+	if err := createNetworkConfig(configName, testConfig.E2EConfig.TargetNetworkRPCURL, networkPassphrase, testConfig.E2EConfig); err != nil {
+		return err
+	}
+	testConfig.NetworkConfigs[configName] = networkPassphrase
+	return nil
+}
+
+func networkConfigShouldBeSavedStep(ctx context.Context, configName string) error {
+
+	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+
+	networkPassphrase, ok := testConfig.NetworkConfigs[configName]
+	if !ok {
+		return fmt.Errorf("network config %s was not created by this scenario", configName)
+	}
+
+	values, err := readNetworkConfigFile(configName, testConfig.E2EConfig)
+	if err != nil {
+		return err
+	}
+
+	var t e2e.Asserter
+	assert.Equal(&t, testConfig.E2EConfig.TargetNetworkRPCURL, values["rpc_url"], "network config %s file rpc_url, Expected %v but got %v", configName, testConfig.E2EConfig.TargetNetworkRPCURL, values["rpc_url"])
+	if t.Err != nil {
+		return t.Err
+	}
+	assert.Equal(&t, networkPassphrase, values["network_passphrase"], "network config %s file network_passphrase, Expected %v but got %v", configName, networkPassphrase, values["network_passphrase"])
+	return t.Err
+}
+
+func removeNetworkConfigStep(ctx context.Context, configName string) error {
+
+	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+
+	if err := removeNetworkConfigFromCliTool(configName, testConfig.E2EConfig); err != nil {
+		return err
+	}
+
+	if _, err := readNetworkConfigFile(configName, testConfig.E2EConfig); err == nil {
+		return fmt.Errorf("network config %s file still exists after it was removed", configName)
+	}
+
+	delete(testConfig.NetworkConfigs, configName)
+	return nil
+}
+
+// configNames is a comma separated list
+func networkConfigsShouldBeListedStep(ctx context.Context, configNames string) error {
+	return networkConfigsListedStep(ctx, configNames, true)
+}
+
+func networkConfigsShouldNotBeListedStep(ctx context.Context, configNames string) error {
+	return networkConfigsListedStep(ctx, configNames, false)
+}
+
+func networkConfigsListedStep(ctx context.Context, configNames string, listed bool) error {
+
+	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+
+	names, err := listNetworkConfigsFromCliTool(testConfig.E2EConfig)
+	if err != nil {
+		return err
+	}
+
+	return namesListed("stellar cli network ls", names, configNames, listed)
+}
+
+// flags are space separated and env vars comma separated KEY=value pairs, in both
+// <rpc_url> and <network_passphrase> are replaced with those of the network under test
+func resolveNetworkStep(ctx context.Context, flags string, envVars string) error {
+
+	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+
+	placeholders := strings.NewReplacer(
+		"<rpc_url>", testConfig.E2EConfig.TargetNetworkRPCURL,
+		"<network_passphrase>", testConfig.E2EConfig.TargetNetworkPassPhrase)
+
+	networkArgs := []string{}
+	for _, flag := range strings.Fields(flags) {
+		networkArgs = append(networkArgs, placeholders.Replace(flag))
+	}
+
+	env := e2e.CommandEnv{}
+	for _, envVar := range strings.Split(envVars, ",") {
+		if strings.TrimSpace(envVar) == "" {
+			continue
+		}
+		key, value, found := strings.Cut(envVar, "=")
+		if !found {
+			return fmt.Errorf("env var %v is not a KEY=value pair", envVar)
+		}
+		env[strings.TrimSpace(key)] = placeholders.Replace(strings.TrimSpace(value))
+	}
+
+	// a failure is kept rather than returned, a later step asserts if it was expected
+	testConfig.ResolvedNetworkContractId, testConfig.ResolvedNetworkStderr, testConfig.ResolvedNetworkErr = resolveNetworkFromCliTool(networkArgs, env, testConfig.E2EConfig)
+	return nil
+}
+
+func networkShouldBeResolvedStep(ctx context.Context, configName string) error {
+
+	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+
+	if testConfig.ResolvedNetworkErr != nil {
+		return testConfig.ResolvedNetworkErr
+	}
+
+	networkPassphrase, ok := testConfig.NetworkConfigs[configName]
+	if !ok {
+		return fmt.Errorf("network config %s was not created by this scenario", configName)
+	}
+
+	expectedContractId, err := nativeAssetContractId(networkPassphrase)
+	if err != nil {
+		return err
+	}
+
+	var t e2e.Asserter
+	assert.Equal(&t, expectedContractId, testConfig.ResolvedNetworkContractId, "stellar cli resolved network, Expected native asset contract id %v of network config %s but got %v", expectedContractId, configName, testConfig.ResolvedNetworkContractId)
+	return t.Err
+}
+
+// the cli must explain the rejection by naming the network option that is missing or conflicting
+func networkShouldBeRejectedStep(ctx context.Context, option string) error {
+
+	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+
+	if testConfig.ResolvedNetworkErr == nil {
+		return fmt.Errorf("stellar cli resolved a network for conflicting network flags, native asset contract id %v", testConfig.ResolvedNetworkContractId)
+	}
+
+	var t e2e.Asserter
+	assert.Contains(&t, testConfig.ResolvedNetworkStderr, option, "stellar cli rejected the network flags, Expected stderr to name %v but got %v", option, testConfig.ResolvedNetworkStderr)
+	return t.Err
 }
 
 func createMyIdentityStep(ctx context.Context, identityName string) error {
@@ -170,11 +388,220 @@ func createTestAccountIdentityStep(ctx context.Context, identityName string) err
 	return nil
 }
 
-func newTestConfig(e2eConfig *e2e.E2EConfig) *testConfig {
-	return &testConfig{
-		E2EConfig:  e2eConfig,
-		Identities: make(map[string]string, 0),
+// the hd index as a cli arg, empty for identities that use the cli default
+func (c *testConfig) identityHDIndex(identityName string) string {
+	if hdIndex, ok := c.IdentityHDIndexes[identityName]; ok {
+		return fmt.Sprint(hdIndex)
+	}
+	return ""
+}
+
+func createSeedPhraseIdentityStep(ctx context.Context, identityName string, hdIndex uint32) error {
+
+	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+
+	seedPhrase, err := newSeedPhrase()
+	if err != nil {
+		return err
+	}
+
+	kp, err := deriveSeedPhraseKeypair(seedPhrase, hdIndex)
+	if err != nil {
+		return err
+	}
+
+	if err := createSeedPhraseIdentityConfig(identityName, seedPhrase, testConfig.E2EConfig); err != nil {
+		return err
+	}
+	testConfig.SeedPhrases[identityName] = seedPhrase
+	testConfig.IdentityHDIndexes[identityName] = hdIndex
+	testConfig.Identities[identityName] = kp.Address()
+	return nil
+}
+
+// the keys the cli derives from the seed phrase at each hd index up to the identity's must match stellar/go's SEP-0005 derivation
+func seedPhraseIdentityKeysShouldMatchStep(ctx context.Context, identityName string) error {
+
+	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+
+	seedPhrase, ok := testConfig.SeedPhrases[identityName]
+	if !ok {
+		return fmt.Errorf("identity %s was not created from a seed phrase by this scenario", identityName)
 	}
+
+	var t e2e.Asserter
+	for hdIndex := uint32(0); hdIndex <= testConfig.IdentityHDIndexes[identityName]; hdIndex++ {
+		expected, err := deriveSeedPhraseKeypair(seedPhrase, hdIndex)
+		if err != nil {
+			return err
+		}
+
+		kp, err := verifyIdentityKeys(identityName, fmt.Sprint(hdIndex), testConfig.E2EConfig)
+		if err != nil {
+			return err
+		}
+
+		assert.Equal(&t, expected.Address(), kp.Address(), "stellar cli keys of identity %s at hd index %v, Expected %v but got %v", identityName, hdIndex, expected.Address(), kp.Address())
+		if t.Err != nil {
+			return t.Err
+		}
+	}
+	return nil
+}
+
+func createIdentityAccountStep(ctx context.Context, identityName string) error {
+
+	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+
+	address, ok := testConfig.Identities[identityName]
+	if !ok {
+		return fmt.Errorf("identity %s was not created by this scenario", identityName)
+	}
+
+	return createAccount(testConfig.E2EConfig, address)
+}
+
+// the generated identity has no known account until it is funded
+func generateIdentityStep(ctx context.Context, identityName string) error {
+
+	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+
+	return generateIdentityFromCliTool(identityName, testConfig.E2EConfig)
+}
+
+// the account friendbot funded must be the one for the secret key the cli shows for the identity
+func fundIdentityStep(ctx context.Context, identityName string) error {
+
+	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+
+	if err := fundIdentityFromCliTool(identityName, testConfig.E2EConfig); err != nil {
+		return err
+	}
+
+	kp, err := identityKeypairFromCliTool(identityName, testConfig.identityHDIndex(identityName), testConfig.E2EConfig)
+	if err != nil {
+		return err
+	}
+
+	accountInfo, err := e2e.QueryAccount(testConfig.E2EConfig, kp.Address())
+	if err != nil {
+		return fmt.Errorf("friendbot did not fund account %v of the secret key of identity %s, %v", kp.Address(), identityName, err)
+	}
+
+	testConfig.Identities[identityName] = accountInfo.ID
+	return nil
+}
+
+// the keys the cli reports must agree with each other, and with the account on the network
+// the identity was added for or funded as
+func identityKeysShouldMatchStep(ctx context.Context, identityName string) error {
+
+	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+
+	expectedAddress, ok := testConfig.Identities[identityName]
+	if !ok {
+		return fmt.Errorf("identity %s has no account on the network from this scenario to match its keys against", identityName)
+	}
+
+	kp, err := verifyIdentityKeys(identityName, testConfig.identityHDIndex(identityName), testConfig.E2EConfig)
+	if err != nil {
+		return err
+	}
+
+	var t e2e.Asserter
+	assert.Equal(&t, expectedAddress, kp.Address(), "stellar cli keys of identity %s, Expected %v but got %v", identityName, expectedAddress, kp.Address())
+	return t.Err
+}
+
+func removeIdentityStep(ctx context.Context, identityName string) error {
+
+	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+
+	if err := removeIdentityFromCliTool(identityName, testConfig.E2EConfig); err != nil {
+		return err
+	}
+
+	if _, err := identityAddressFromCliTool(identityName, "", testConfig.E2EConfig); err == nil {
+		return fmt.Errorf("stellar cli still has an address for identity %s after it was removed", identityName)
+	}
+
+	delete(testConfig.Identities, identityName)
+	delete(testConfig.SeedPhrases, identityName)
+	delete(testConfig.IdentityHDIndexes, identityName)
+	return nil
+}
+
+// identityNames is a comma separated list
+func identitiesShouldBeListedStep(ctx context.Context, identityNames string) error {
+	return identitiesListedStep(ctx, identityNames, true)
+}
+
+func identitiesShouldNotBeListedStep(ctx context.Context, identityNames string) error {
+	return identitiesListedStep(ctx, identityNames, false)
+}
+
+func identitiesListedStep(ctx context.Context, identityNames string, listed bool) error {
+
+	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+
+	names, err := listIdentitiesFromCliTool(testConfig.E2EConfig)
+	if err != nil {
+		return err
+	}
+
+	return namesListed("stellar cli keys ls", names, identityNames, listed)
+}
+
+// checks each of the comma separated expectedNames is in, or not in, the names a cli ls command listed
+func namesListed(command string, names []string, expectedNames string, listed bool) error {
+	var t e2e.Asserter
+	for _, name := range strings.Split(expectedNames, ",") {
+		name = strings.TrimSpace(name)
+		if listed {
+			assert.Contains(&t, names, name, "%s, Expected %v in %v", command, name, names)
+		} else {
+			assert.NotContains(&t, names, name, "%s, Expected no %v in %v", command, name, names)
+		}
+		if t.Err != nil {
+			return t.Err
+		}
+	}
+	return nil
+}
+
+// unique per scenario and example row, safe to use as a file name
+func transcriptFileName(scenario *godog.Scenario) string {
+	name := scenario.Name
+	if len(name) > 100 {
+		name = name[:100]
+	}
+	name = regexp.MustCompile(`[^A-Za-z0-9_-]+`).ReplaceAllString(name+"_"+scenario.Id, "_")
+	return strings.Trim(name, "_") + ".log"
+}
+
+// the scenario gets its own copy of the config, with cli identity and network configs
+// kept in the scenario workspace rather than the user's global stellar config
+func newTestConfig(e2eConfig *e2e.E2EConfig, workspace string) (*testConfig, error) {
+	cliConfigHome := filepath.Join(workspace, "stellar_config")
+	if err := os.MkdirAll(cliConfigHome, 0755); err != nil {
+		return nil, fmt.Errorf("could not create cli config directory %s, %v", cliConfigHome, err)
+	}
+
+	scenarioConfig := *e2eConfig
+	scenarioConfig.CommandEnv = e2e.CommandEnv{}
+	for key, value := range e2eConfig.CommandEnv {
+		scenarioConfig.CommandEnv[key] = value
+	}
+	scenarioConfig.CommandEnv["STELLAR_CONFIG_HOME"] = cliConfigHome
+
+	return &testConfig{
+		E2EConfig:         &scenarioConfig,
+		Identities:        make(map[string]string, 0),
+		SeedPhrases:       make(map[string]string, 0),
+		IdentityHDIndexes: make(map[string]uint32, 0),
+		NetworkConfigs:    make(map[string]string, 0),
+		TestWorkingDir:    workspace,
+	}, nil
 }
 
 func getNetworkStep(ctx context.Context) error {
@@ -191,6 +618,22 @@ func getNetworkStep(ctx context.Context) error {
 	return nil
 }
 
+// the limit is enforced by TxSub, so it only applies to transactions the GO tool submits,
+// the cli and js sdk poll for confirmation themselves
+func txConfirmedWithinLedgersStep(ctx context.Context, tool string, ledgers uint32) error {
+	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+
+	if tool != "GO" {
+		return fmt.Errorf("%s tool not supported for tx confirmation ledger limit, only GO tool transactions are submitted with TxSub", tool)
+	}
+
+	// copy the config, so the override only applies to this scenario
+	e2eConfig := *testConfig.E2EConfig
+	e2eConfig.TxConfirmLedgers = ledgers
+	testConfig.E2EConfig = &e2eConfig
+	return nil
+}
+
 func theResultShouldBeStep(ctx context.Context, expectedResult string) error {
 	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
 
@@ -223,6 +666,151 @@ func theContractEventsShouldBeStep(ctx context.Context, expectedContractEventsCo
 	return nil
 }
 
+// each row of expectedEvents is an event which must have been emitted, the Topic column
+// is comma separated, Topic and Value compare same as theInvocationContractEventShouldBeStep
+func theContractEventsShouldIncludeStep(ctx context.Context, contractName string, tool string, expectedEvents *godog.Table) error {
+	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+
+	if len(expectedEvents.Rows) < 1 {
+		return fmt.Errorf("expected events table for %v has no header row", contractName)
+	}
+	topicColumn, valueColumn := -1, -1
+	for i, cell := range expectedEvents.Rows[0].Cells {
+		switch cell.Value {
+		case "Topic":
+			topicColumn = i
+		case "Value":
+			valueColumn = i
+		}
+	}
+	if topicColumn < 0 || valueColumn < 0 {
+		return fmt.Errorf("expected events table for %v must have Topic and Value columns", contractName)
+	}
+
+	contractEvents, err := getEvents(testConfig.InitialNetworkState.Sequence, testConfig.DeployedContractId, tool, 100, testConfig.E2EConfig)
+	if err != nil {
+		return err
+	}
+
+	emittedEvents := []string{}
+	for _, contractEvent := range contractEvents {
+		topics := []string{}
+		for _, topic := range contractEvent.Topic {
+			topicString, err := scValToDisplayString(topic)
+			if err != nil {
+				return err
+			}
+			topics = append(topics, topicString)
+		}
+		value, err := scValToDisplayString(contractEvent.Value)
+		if err != nil {
+			return err
+		}
+		emittedEvents = append(emittedEvents, fmt.Sprintf("topic %v value %v", strings.Join(topics, ","), value))
+	}
+
+	var t e2e.Asserter
+	for _, row := range expectedEvents.Rows[1:] {
+		expectedEvent := fmt.Sprintf("topic %v value %v", row.Cells[topicColumn].Value, row.Cells[valueColumn].Value)
+		assert.Contains(&t, emittedEvents, expectedEvent, "Expected event with %v for %v using %v but got %v", expectedEvent, contractName, tool, emittedEvents)
+		if t.Err != nil {
+			return t.Err
+		}
+	}
+
+	return nil
+}
+
+// walks all pages of the deployed contract's events, to verify rpc pagination
+func pagedContractEventsCountShouldBeStep(ctx context.Context, contractName string, pageSize uint, expectedContractEventsCount int) error {
+	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+
+	request := e2e.GetEventsRequest{
+		StartLedger: testConfig.InitialNetworkState.Sequence,
+		Filters: []e2e.EventFilter{{
+			EventType:   e2e.EventTypeContract,
+			ContractIDs: []string{testConfig.DeployedContractId},
+		}},
+		Pagination: &e2e.PaginationOptions{Limit: pageSize},
+	}
+
+	contractEventsCount := 0
+	for _, err := range e2e.AllEvents(testConfig.E2EConfig, request) {
+		if err != nil {
+			return err
+		}
+		contractEventsCount++
+	}
+
+	var t e2e.Asserter
+	assert.Equal(&t, expectedContractEventsCount, contractEventsCount, "Expected %v contract events for %v paging %v at a time but got %v", expectedContractEventsCount, contractName, pageSize, contractEventsCount)
+	return t.Err
+}
+
+func theInvocationContractEventsCountShouldBeStep(ctx context.Context, expectedContractEventsCount int) error {
+	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+	if err := testConfig.captureInvocationEvents(); err != nil {
+		return err
+	}
+
+	var t e2e.Asserter
+	assert.Len(&t, testConfig.ContractEvents, expectedContractEventsCount, "Expected %v contract events from invocation but got %v", expectedContractEventsCount, len(testConfig.ContractEvents))
+	return t.Err
+}
+
+// expectedTopics is comma separated, expectedData and each topic compare against the
+// json of their native value, with strings unquoted, i.e. COUNTER,increment and 1
+func theInvocationContractEventShouldBeStep(ctx context.Context, eventNumber int, expectedTopics string, expectedData string) error {
+	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+	if err := testConfig.captureInvocationEvents(); err != nil {
+		return err
+	}
+
+	if eventNumber < 1 || eventNumber > len(testConfig.ContractEvents) {
+		return fmt.Errorf("invocation contract event %v does not exist, invocation emitted %v contract events", eventNumber, len(testConfig.ContractEvents))
+	}
+	body, ok := testConfig.ContractEvents[eventNumber-1].Body.GetV0()
+	if !ok {
+		return fmt.Errorf("invocation contract event %v has unsupported body version %v", eventNumber, testConfig.ContractEvents[eventNumber-1].Body.V)
+	}
+
+	topics := []string{}
+	for _, topic := range body.Topics {
+		topicString, err := scValToDisplayString(topic)
+		if err != nil {
+			return err
+		}
+		topics = append(topics, topicString)
+	}
+	data, err := scValToDisplayString(body.Data)
+	if err != nil {
+		return err
+	}
+
+	var t e2e.Asserter
+	assert.Equal(&t, expectedTopics, strings.Join(topics, ","), "Expected invocation contract event %v topics %v but got %v", eventNumber, expectedTopics, strings.Join(topics, ","))
+	if t.Err != nil {
+		return t.Err
+	}
+	assert.Equal(&t, expectedData, data, "Expected invocation contract event %v data %v but got %v", eventNumber, expectedData, data)
+	return t.Err
+}
+
+func scValToDisplayString(value xdr.ScVal) (string, error) {
+	native, err := scValToNative(value)
+	if err != nil {
+		return "", err
+	}
+	if nativeString, ok := native.(string); ok {
+		return nativeString, nil
+	}
+	serialized, err := json.Marshal(native)
+	if err != nil {
+		return "", err
+	}
+	return string(serialized), nil
+}
+
 func queryAccountStep(ctx context.Context) error {
 	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
 
@@ -240,10 +828,26 @@ func queryAccountStep(ctx context.Context) error {
 func createTesterAccountStep(ctx context.Context) error {
 	testConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
 
-	kp := keypair.MustParseFull(testConfig.E2EConfig.TargetNetworkSecretKey)
+	testerKp, err := keypair.Random()
+	if err != nil {
+		return fmt.Errorf("unable to generate key pair for tester account had error %e", err)
+	}
+
+	if err := createAccount(testConfig.E2EConfig, testerKp.Address()); err != nil {
+		return err
+	}
+
+	testConfig.TesterAccountPublicKey = testerKp.Address()
+	testConfig.TesterAccountPrivateKey = testerKp.Seed()
+	return nil
+}
+
+// submits a transaction from my account to create and fund the destination account
+func createAccount(e2eConfig *e2e.E2EConfig, destination string) error {
+	kp := keypair.MustParseFull(e2eConfig.TargetNetworkSecretKey)
 	address := kp.Address()
 
-	addressState, err := e2e.QueryAccount(testConfig.E2EConfig, address)
+	addressState, err := e2e.QueryAccount(e2eConfig, address)
 
 	if err != nil {
 		return fmt.Errorf("unable to query latest account state for %v, had error %e", address, err)
@@ -251,17 +855,12 @@ func createTesterAccountStep(ctx context.Context) error {
 
 	account := txnbuild.NewSimpleAccount(address, addressState.Sequence)
 
-	testerKp, err := keypair.Random()
-	if err != nil {
-		return fmt.Errorf("unable to generate key pair for tester account had error %e", err)
-	}
-
 	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
 		SourceAccount:        &account,
 		IncrementSequenceNum: true,
 		Operations: []txnbuild.Operation{
 			&txnbuild.CreateAccount{
-				Destination:   testerKp.Address(),
+				Destination:   destination,
 				Amount:        "100",
 				SourceAccount: address,
 			},
@@ -272,78 +871,139 @@ func createTesterAccountStep(ctx context.Context) error {
 		},
 	})
 	if err != nil {
-		return fmt.Errorf("building transaction to create tester account had error %e", err)
+		return fmt.Errorf("building transaction to create account %v had error %e", destination, err)
 	}
 
-	tx, err = tx.Sign(testConfig.E2EConfig.TargetNetworkPassPhrase, kp)
+	tx, err = tx.Sign(e2eConfig.TargetNetworkPassPhrase, kp)
 	if err != nil {
-		return fmt.Errorf("signing transaction to create tester account had error %v, %e", tx, err)
+		return fmt.Errorf("signing transaction to create account %v had error %v, %e", destination, tx, err)
 	}
 
-	_, err = e2e.TxSub(testConfig.E2EConfig, tx)
+	_, err = e2e.TxSub(e2eConfig, tx)
 	if err != nil {
-		return fmt.Errorf("not able to submit transaction to create tester account %e", err)
+		return fmt.Errorf("not able to submit transaction to create account %v %w", destination, err)
 	}
 
-	if testConfig.E2EConfig.VerboseOutput {
-		fmt.Fprintf(os.Stdout, "created and funded test accout %v", testerKp.Address())
+	if e2eConfig.VerboseOutput {
+		fmt.Fprintf(os.Stdout, "created and funded test accout %v", destination)
 	}
 
-	testConfig.TesterAccountPublicKey = testerKp.Address()
-	testConfig.TesterAccountPrivateKey = testerKp.Seed()
 	return nil
 }
 
 func initializeScenario(scenarioCtx *godog.ScenarioContext) {
-	scenarioCtx.Before(func(ctx context.Context, _ *godog.Scenario) (context.Context, error) {
+	scenarioCtx.Before(func(ctx context.Context, scenario *godog.Scenario) (context.Context, error) {
 
 		e2eConfig := ctx.Value(e2e.TestConfigContextKey).(*e2e.E2EConfig)
 
-		testConfig := newTestConfig(e2eConfig)
-
-		envCmd := cmd.NewCmd("rm", "-rf", e2e.TestTmpDirectory)
-		status, _, err := e2e.RunCommand(envCmd, testConfig.E2EConfig)
-
-		if status != 0 || err != nil {
-			return nil, fmt.Errorf("could not remove %s directory, had error %v, %v", e2e.TestTmpDirectory, status, err)
+		workspace, err := e2e.NewTestWorkspace()
+		if err != nil {
+			return nil, err
 		}
 
-		envCmd = cmd.NewCmd("mkdir", e2e.TestTmpDirectory)
-		status, _, err = e2e.RunCommand(envCmd, testConfig.E2EConfig)
+		testConfig, err := newTestConfig(e2eConfig, workspace)
+		if err != nil {
+			os.RemoveAll(workspace)
+			return nil, err
+		}
 
-		if status != 0 || err != nil {
-			return nil, fmt.Errorf("could not initialize %s directory, had error %v, %v", e2e.TestTmpDirectory, status, err)
+		transcriptPath := filepath.Join(e2eConfig.TranscriptDirectory, transcriptFileName(scenario))
+		if testConfig.E2EConfig.Transcript, err = e2e.NewTranscript(transcriptPath); err != nil {
+			os.RemoveAll(workspace)
+			return nil, err
 		}
 
-		testConfig.TestWorkingDir = e2e.TestTmpDirectory
+		// the scenario signs with its own account from the pool rather than the shared root account,
+		// waiting no longer than a command would for one to be returned
+		if e2eConfig.AccountPool != nil {
+			leaseCtx, cancel := context.WithTimeout(ctx, e2eConfig.CommandTimeout)
+			testConfig.LeasedAccount, err = e2eConfig.AccountPool.Lease(leaseCtx)
+			cancel()
+			if err != nil {
+				testConfig.E2EConfig.Transcript.Close()
+				os.Remove(transcriptPath)
+				os.RemoveAll(workspace)
+				return nil, fmt.Errorf("scenario %q could not lease a pool account, %w", scenario.Name, err)
+			}
+			testConfig.E2EConfig.TargetNetworkSecretKey = testConfig.LeasedAccount.Seed()
+			testConfig.E2EConfig.TargetNetworkPublicKey = testConfig.LeasedAccount.Address()
+		}
 		ctx = context.WithValue(ctx, e2e.TestConfigContextKey, testConfig)
 
 		scenarioCtx.Step(`^I am using an rpc instance that has captive core config, ENABLE_SOROBAN_DIAGNOSTIC_EVENTS=true$`, noOpStep)
 		scenarioCtx.Step(`^I used cargo to compile example contract ([\S|\s]+)$`, compileContractStep)
 		scenarioCtx.Step(`^I used rpc to verify my account is on the network`, queryAccountStep)
 		scenarioCtx.Step(`^I used rpc to get network latest ledger$`, getNetworkStep)
+		scenarioCtx.Step(`^I expect transactions submitted from tool (\S+) to be confirmed within (\d+) ledgers$`, txConfirmedWithinLedgersStep)
 		scenarioCtx.Step(`^I used rpc to submit transaction to create tester account on the network$`, createTesterAccountStep)
 		scenarioCtx.Step(`^I used cli to add Network Config ([\S|\s]+) for rpc and standalone$`, createNetworkConfigStep)
+		scenarioCtx.Step(`^I used cli to add Network Config (\S+) for rpc and passphrase ([\S|\s]+)$`, createNetworkConfigWithPassphraseStep)
+		scenarioCtx.Step(`^The cli network config ([\S|\s]+) should be saved with its rpc url and passphrase$`, networkConfigShouldBeSavedStep)
+		scenarioCtx.Step(`^I used cli to remove Network Config ([\S|\s]+)$`, removeNetworkConfigStep)
+		scenarioCtx.Step(`^The cli should list Network Configs ([\S|\s]+)$`, networkConfigsShouldBeListedStep)
+		scenarioCtx.Step(`^The cli should not list Network Configs ([\S|\s]+)$`, networkConfigsShouldNotBeListedStep)
+		scenarioCtx.Step(`^I used cli to resolve the network with flags ([\S|\s]*) and env vars ?([\S|\s]*)$`, resolveNetworkStep)
+		scenarioCtx.Step(`^The cli should have resolved Network Config ([\S|\s]+)$`, networkShouldBeResolvedStep)
+		scenarioCtx.Step(`^The cli should have rejected the network flags for option (\S+)$`, networkShouldBeRejectedStep)
 		scenarioCtx.Step(`^I used cli to add Identity ([\S|\s]+) for my secret key$`, createMyIdentityStep)
 		scenarioCtx.Step(`^I used cli to deploy contract ([\S|\s]+) / ([\S|\s]+) using Identity ([\S|\s]+) and Network Config ([\S|\s]+)$`, deployContractUsingConfigParamsStep)
 		scenarioCtx.Step(`^I used cli to install contract ([\S|\s]+) / ([\S|\s]+) on network using my secret key$`, installContractStep)
 		scenarioCtx.Step(`^I used cli to deploy contract ([\S|\s]+) / ([\S|\s]+) by installed hash using my secret key$`, deployContractStep)
 		scenarioCtx.Step(`^I used cli to deploy contract ([\S|\s]+) / ([\S|\s]+) using my secret key$`, deployContractStep)
+		scenarioCtx.Step(`^I deploy contract ([\S|\s]+) / ([\S|\s]+) from tool ([\S|\s]+) using my secret key$`, deployContractFromToolStep)
 		scenarioCtx.Step(`^I used cli to add Identity ([\S|\s]+) for tester secret key$`, createTestAccountIdentityStep)
+		scenarioCtx.Step(`^I used cli to add Identity ([\S|\s]+) for a new seed phrase at HD index (\d+)$`, createSeedPhraseIdentityStep)
+		scenarioCtx.Step(`^The cli keys for Identity ([\S|\s]+) should match the SEP-0005 derivation of its seed phrase$`, seedPhraseIdentityKeysShouldMatchStep)
+		scenarioCtx.Step(`^I used rpc to submit transaction to create the account of Identity ([\S|\s]+) on the network$`, createIdentityAccountStep)
+		scenarioCtx.Step(`^I used cli to generate Identity ([\S|\s]+)$`, generateIdentityStep)
+		scenarioCtx.Step(`^I used cli to fund Identity ([\S|\s]+) from friendbot$`, fundIdentityStep)
+		scenarioCtx.Step(`^I used cli to remove Identity ([\S|\s]+)$`, removeIdentityStep)
+		scenarioCtx.Step(`^The cli keys for Identity ([\S|\s]+) should match an independently derived keypair$`, identityKeysShouldMatchStep)
+		scenarioCtx.Step(`^The cli should list Identities ([\S|\s]+)$`, identitiesShouldBeListedStep)
+		scenarioCtx.Step(`^The cli should not list Identities ([\S|\s]+)$`, identitiesShouldNotBeListedStep)
 		scenarioCtx.Step(`^I invoke function ([\S|\s]+) on ([\S|\s]+) with request parameters ([\S|\s]*) from tool ([\S|\s]+) using Identity ([\S|\s]+) as invoker and Network Config ([\S|\s]+)$`, invokeContractStepWithConfig)
 		scenarioCtx.Step(`^I invoke function ([\S|\s]+) on ([\S|\s]+) with request parameters ([\S|\s]*) from tool ([\S|\s]+) using my secret key$`, invokeContractStep)
 		scenarioCtx.Step(`^The result should be (\S+)$`, theResultShouldBeStep)
 		scenarioCtx.Step(`^The result should be to receive ([\S|\s]+) contract events for ([\S|\s]+) from ([\S|\s]+)$`, theContractEventsShouldBeStep)
+		scenarioCtx.Step(`^The contract events for ([\S|\s]+) from ([\S|\s]+) should include$`, theContractEventsShouldIncludeStep)
+		scenarioCtx.Step(`^Paging through contract events for ([\S|\s]+) (\d+) at a time should yield (\d+) contract events$`, pagedContractEventsCountShouldBeStep)
+		scenarioCtx.Step(`^The invocation should have emitted (\d+) contract events$`, theInvocationContractEventsCountShouldBeStep)
+		scenarioCtx.Step(`^The invocation contract event (\d+) should have topics (\S+) and data (\S+)$`, theInvocationContractEventShouldBeStep)
 
 		return ctx, nil
 	})
-	scenarioCtx.After(func(ctx context.Context, _ *godog.Scenario, _ error) (context.Context, error) {
-		e2eConfig := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
-		envCmd := cmd.NewCmd("rm", "-rf", e2e.TestTmpDirectory)
-		status, _, err := e2e.RunCommand(envCmd, e2eConfig.E2EConfig)
+	scenarioCtx.After(func(ctx context.Context, scenario *godog.Scenario, scenarioErr error) (context.Context, error) {
+		testConfig, ok := ctx.Value(e2e.TestConfigContextKey).(*testConfig)
+		if !ok {
+			// scenario did not get as far as creating its workspace
+			return ctx, nil
+		}
+
+		if testConfig.LeasedAccount != nil {
+			testConfig.E2EConfig.AccountPool.Return(testConfig.LeasedAccount)
+		}
+
+		// transcripts of failed scenarios are kept
+		transcript := testConfig.E2EConfig.Transcript
+		if err := transcript.Close(); err != nil {
+			return nil, fmt.Errorf("could not close transcript %s, had error %v", transcript.Path(), err)
+		}
+		if scenarioErr != nil {
+			fmt.Printf("\nScenario %q failed, kept transcript: %s\n", scenario.Name, transcript.Path())
+			if testConfig.LeasedAccount != nil {
+				fmt.Printf("Scenario %q failed, signed with pool account: %s\n", scenario.Name, testConfig.LeasedAccount.Address())
+			}
+		} else if err := os.Remove(transcript.Path()); err != nil {
+			return nil, fmt.Errorf("could not remove transcript %s, had error %v", transcript.Path(), err)
+		}
+
+		if scenarioErr != nil && testConfig.E2EConfig.KeepWorkspaceOnFailure {
+			fmt.Printf("\nScenario %q failed, kept workspace: %s\n", scenario.Name, testConfig.TestWorkingDir)
+			return ctx, nil
+		}
 
-		if status != 0 || err != nil {
-			return nil, fmt.Errorf("could not remove %s directory, had error %v, %v", e2e.TestTmpDirectory, status, err)
+		if err := os.RemoveAll(testConfig.TestWorkingDir); err != nil {
+			return nil, fmt.Errorf("could not remove %s directory, had error %v", testConfig.TestWorkingDir, err)
 		}
 		return ctx, nil
 	})
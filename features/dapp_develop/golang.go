@@ -0,0 +1,366 @@
+package dapp_develop
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+
+	e2e "github.com/stellar/system-test"
+)
+
+// return the fn response as a serialized json string and the hash of the invocation tx
+// function params are comma separated, each formatted as name:type:value, i.e. to:string:Aloha
+// uses secret-key and network-passphrase from e2e config directly
+func invokeContractFromGoTool(deployedContractId, contractName, functionName, functionParams string, e2eConfig *e2e.E2EConfig) (string, string, error) {
+	contractAddress, err := contractScAddress(deployedContractId)
+	if err != nil {
+		return "", "", fmt.Errorf("go invoke of example contract %s had error %v", contractName, err)
+	}
+
+	args, err := parseGoToolFunctionParams(functionParams)
+	if err != nil {
+		return "", "", fmt.Errorf("go invoke of example contract %s had error %v", contractName, err)
+	}
+
+	returnValue, txHash, err := submitHostFunctionFromGoTool(xdr.HostFunction{
+		Type: xdr.HostFunctionTypeHostFunctionTypeInvokeContract,
+		InvokeContract: &xdr.InvokeContractArgs{
+			ContractAddress: contractAddress,
+			FunctionName:    xdr.ScSymbol(functionName),
+			Args:            args,
+		},
+	}, e2eConfig)
+	if err != nil {
+		return "", "", fmt.Errorf("go invoke of example contract %s had error %v", contractName, err)
+	}
+
+	native, err := scValToNative(returnValue)
+	if err != nil {
+		return "", "", fmt.Errorf("go invoke of example contract %s, not able to convert return value, %v", contractName, err)
+	}
+
+	response, err := json.Marshal(native)
+	if err != nil {
+		return "", "", fmt.Errorf("go invoke of example contract %s, not able to serialize return value, %v", contractName, err)
+	}
+
+	return string(response), txHash, nil
+}
+
+// invokes the contract using identities and network from prior setup of config state in cli
+func invokeContractFromGoToolWithConfig(deployedContractId, contractName, functionName, parameters, identity, identityHDIndex, networkConfig string, e2eConfig *e2e.E2EConfig) (string, string, error) {
+	return "", "", fmt.Errorf("invoke with named identity not supported for GO tool")
+}
+
+// returns the deployed contract id
+// uploads the wasm first, unless the hash of an already installed wasm is provided
+func deployContractFromGoTool(wasmFilePath string, installedContractId string, e2eConfig *e2e.E2EConfig) (string, error) {
+	var err error
+	wasmHash := installedContractId
+
+	if wasmHash == "" {
+		if wasmHash, err = installContractFromGoTool(wasmFilePath, e2eConfig); err != nil {
+			return "", err
+		}
+		if err = verifyInstalledWasm(wasmFilePath, wasmHash, e2eConfig); err != nil {
+			return "", err
+		}
+	}
+
+	var hash xdr.Hash
+	decodedHash, err := hex.DecodeString(wasmHash)
+	if err != nil || len(decodedHash) != len(hash) {
+		return "", fmt.Errorf("go deploy of contract, invalid wasm hash %v", wasmHash)
+	}
+	copy(hash[:], decodedHash)
+
+	var salt xdr.Uint256
+	if _, err = rand.Read(salt[:]); err != nil {
+		return "", fmt.Errorf("go deploy of contract, not able to generate salt, %v", err)
+	}
+
+	deployer, err := xdr.AddressToAccountId(keypair.MustParseFull(e2eConfig.TargetNetworkSecretKey).Address())
+	if err != nil {
+		return "", fmt.Errorf("go deploy of contract, invalid deployer account, %v", err)
+	}
+
+	returnValue, _, err := submitHostFunctionFromGoTool(xdr.HostFunction{
+		Type: xdr.HostFunctionTypeHostFunctionTypeCreateContractV2,
+		CreateContractV2: &xdr.CreateContractArgsV2{
+			ContractIdPreimage: xdr.ContractIdPreimage{
+				Type: xdr.ContractIdPreimageTypeContractIdPreimageFromAddress,
+				FromAddress: &xdr.ContractIdPreimageFromAddress{
+					Address: xdr.ScAddress{
+						Type:      xdr.ScAddressTypeScAddressTypeAccount,
+						AccountId: &deployer,
+					},
+					Salt: salt,
+				},
+			},
+			Executable: xdr.ContractExecutable{
+				Type:     xdr.ContractExecutableTypeContractExecutableWasm,
+				WasmHash: &hash,
+			},
+		},
+	}, e2eConfig)
+	if err != nil {
+		return "", fmt.Errorf("go deploy of contract %s had error %v", wasmFilePath, err)
+	}
+
+	contractAddress, ok := returnValue.GetAddress()
+	if !ok {
+		return "", fmt.Errorf("go deploy of contract %s did not return a contract address, %v", wasmFilePath, returnValue)
+	}
+
+	return contractAddress.String()
+}
+
+// returns the hex encoded hash of the installed wasm
+func installContractFromGoTool(wasmFilePath string, e2eConfig *e2e.E2EConfig) (string, error) {
+	wasm, err := os.ReadFile(wasmFilePath)
+	if err != nil {
+		return "", fmt.Errorf("go install of contract, not able to read wasm file %s, %v", wasmFilePath, err)
+	}
+
+	returnValue, _, err := submitHostFunctionFromGoTool(xdr.HostFunction{
+		Type: xdr.HostFunctionTypeHostFunctionTypeUploadContractWasm,
+		Wasm: &wasm,
+	}, e2eConfig)
+	if err != nil {
+		return "", fmt.Errorf("go install of contract %s had error %v", wasmFilePath, err)
+	}
+
+	wasmHash, ok := returnValue.GetBytes()
+	if !ok {
+		return "", fmt.Errorf("go install of contract %s did not return a wasm hash, %v", wasmFilePath, returnValue)
+	}
+
+	return hex.EncodeToString(wasmHash), nil
+}
+
+func getEventsFromGoTool(ledgerFrom uint32, deployedContractId string, size uint32, e2eConfig *e2e.E2EConfig) ([]e2e.ContractEvent, error) {
+	request := e2e.GetEventsRequest{
+		StartLedger: ledgerFrom,
+		Filters:     []e2e.EventFilter{},
+		Pagination:  &e2e.PaginationOptions{Limit: uint(size)},
+	}
+	if deployedContractId != "" {
+		request.Filters = append(request.Filters, e2e.EventFilter{ContractIDs: []string{deployedContractId}})
+	}
+
+	response, err := e2e.GetEvents(e2eConfig, request)
+	if err != nil {
+		return nil, fmt.Errorf("go get events had error %v", err)
+	}
+
+	contractEvents, err := e2e.ContractEventsFromEventInfos(response.Events)
+	if err != nil {
+		return nil, fmt.Errorf("go get events response had invalid event, %v", err)
+	}
+
+	return contractEvents, nil
+}
+
+// prepares, signs and submits the host function with the e2e config account as source,
+// returns the host function return value from the transaction meta and the tx hash
+func submitHostFunctionFromGoTool(hostFunction xdr.HostFunction, e2eConfig *e2e.E2EConfig) (xdr.ScVal, string, error) {
+	kp := keypair.MustParseFull(e2eConfig.TargetNetworkSecretKey)
+
+	accountInfo, err := e2e.QueryAccount(e2eConfig, kp.Address())
+	if err != nil {
+		return xdr.ScVal{}, "", err
+	}
+	account := txnbuild.NewSimpleAccount(kp.Address(), accountInfo.Sequence)
+
+	tx, _, err := e2e.PrepareTransaction(e2eConfig, &account, &txnbuild.InvokeHostFunction{
+		HostFunction:  hostFunction,
+		SourceAccount: kp.Address(),
+	}, kp)
+	if err != nil {
+		return xdr.ScVal{}, "", err
+	}
+
+	txHash, err := tx.HashHex(e2eConfig.TargetNetworkPassPhrase)
+	if err != nil {
+		return xdr.ScVal{}, "", fmt.Errorf("not able to generate tx hash, %v", err)
+	}
+
+	txStatus, err := e2e.TxSub(e2eConfig, tx)
+	if err != nil {
+		return xdr.ScVal{}, "", err
+	}
+
+	returnValue, err := txStatus.ReturnValue()
+	return returnValue, txHash, err
+}
+
+func contractScAddress(contractId string) (xdr.ScAddress, error) {
+	decoded, err := strkey.Decode(strkey.VersionByteContract, contractId)
+	if err != nil {
+		return xdr.ScAddress{}, fmt.Errorf("invalid contract id %v, %v", contractId, err)
+	}
+
+	var id xdr.ContractId
+	copy(id[:], decoded)
+	return xdr.ScAddress{
+		Type:       xdr.ScAddressTypeScAddressTypeContract,
+		ContractId: &id,
+	}, nil
+}
+
+func parseGoToolFunctionParams(functionParams string) ([]xdr.ScVal, error) {
+	args := []xdr.ScVal{}
+	if functionParams == "" {
+		return args, nil
+	}
+
+	for _, param := range strings.Split(functionParams, ",") {
+		parts := strings.SplitN(param, ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("function param %v is not in name:type:value format", param)
+		}
+
+		arg, err := parseScVal(parts[1], parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("function param %v had error %v", parts[0], err)
+		}
+		args = append(args, arg)
+	}
+
+	return args, nil
+}
+
+func parseScVal(valueType string, value string) (xdr.ScVal, error) {
+	switch valueType {
+	case "string":
+		str := xdr.ScString(value)
+		return xdr.ScVal{Type: xdr.ScValTypeScvString, Str: &str}, nil
+	case "symbol":
+		sym := xdr.ScSymbol(value)
+		return xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &sym}, nil
+	case "bool":
+		b, err := strconv.ParseBool(value)
+		return xdr.ScVal{Type: xdr.ScValTypeScvBool, B: &b}, err
+	case "u32":
+		n, err := strconv.ParseUint(value, 10, 32)
+		u32 := xdr.Uint32(n)
+		return xdr.ScVal{Type: xdr.ScValTypeScvU32, U32: &u32}, err
+	case "i32":
+		n, err := strconv.ParseInt(value, 10, 32)
+		i32 := xdr.Int32(n)
+		return xdr.ScVal{Type: xdr.ScValTypeScvI32, I32: &i32}, err
+	case "u64":
+		n, err := strconv.ParseUint(value, 10, 64)
+		u64 := xdr.Uint64(n)
+		return xdr.ScVal{Type: xdr.ScValTypeScvU64, U64: &u64}, err
+	case "i64":
+		n, err := strconv.ParseInt(value, 10, 64)
+		i64 := xdr.Int64(n)
+		return xdr.ScVal{Type: xdr.ScValTypeScvI64, I64: &i64}, err
+	case "i128":
+		n, ok := new(big.Int).SetString(value, 10)
+		if !ok {
+			return xdr.ScVal{}, fmt.Errorf("invalid i128 value %v", value)
+		}
+		lo := new(big.Int).And(n, new(big.Int).SetUint64(^uint64(0)))
+		hi := new(big.Int).Rsh(n, 64)
+		parts := xdr.Int128Parts{Hi: xdr.Int64(hi.Int64()), Lo: xdr.Uint64(lo.Uint64())}
+		return xdr.ScVal{Type: xdr.ScValTypeScvI128, I128: &parts}, nil
+	case "address":
+		if strings.HasPrefix(value, "C") {
+			return contractScVal(value)
+		}
+		accountId, err := xdr.AddressToAccountId(value)
+		if err != nil {
+			return xdr.ScVal{}, err
+		}
+		address := xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeAccount, AccountId: &accountId}
+		return xdr.ScVal{Type: xdr.ScValTypeScvAddress, Address: &address}, nil
+	case "bytes":
+		decoded, err := hex.DecodeString(value)
+		bytes := xdr.ScBytes(decoded)
+		return xdr.ScVal{Type: xdr.ScValTypeScvBytes, Bytes: &bytes}, err
+	}
+
+	return xdr.ScVal{}, fmt.Errorf("unsupported param type %v", valueType)
+}
+
+func contractScVal(contractId string) (xdr.ScVal, error) {
+	address, err := contractScAddress(contractId)
+	if err != nil {
+		return xdr.ScVal{}, err
+	}
+	return xdr.ScVal{Type: xdr.ScValTypeScvAddress, Address: &address}, nil
+}
+
+// converts to go values which serialize to same json as the js sdk scValToNative
+func scValToNative(value xdr.ScVal) (interface{}, error) {
+	switch value.Type {
+	case xdr.ScValTypeScvVoid:
+		return nil, nil
+	case xdr.ScValTypeScvBool:
+		return *value.B, nil
+	case xdr.ScValTypeScvU32:
+		return uint32(*value.U32), nil
+	case xdr.ScValTypeScvI32:
+		return int32(*value.I32), nil
+	case xdr.ScValTypeScvU64:
+		return uint64(*value.U64), nil
+	case xdr.ScValTypeScvI64:
+		return int64(*value.I64), nil
+	case xdr.ScValTypeScvU128:
+		n := new(big.Int).Lsh(new(big.Int).SetUint64(uint64(value.U128.Hi)), 64)
+		return n.Or(n, new(big.Int).SetUint64(uint64(value.U128.Lo))).String(), nil
+	case xdr.ScValTypeScvI128:
+		n := new(big.Int).Lsh(big.NewInt(int64(value.I128.Hi)), 64)
+		return n.Or(n, new(big.Int).SetUint64(uint64(value.I128.Lo))).String(), nil
+	case xdr.ScValTypeScvString:
+		return string(*value.Str), nil
+	case xdr.ScValTypeScvSymbol:
+		return string(*value.Sym), nil
+	case xdr.ScValTypeScvBytes:
+		return hex.EncodeToString(*value.Bytes), nil
+	case xdr.ScValTypeScvAddress:
+		return value.Address.String()
+	case xdr.ScValTypeScvVec:
+		native := []interface{}{}
+		if vec, _ := value.GetVec(); vec != nil {
+			for _, item := range *vec {
+				nativeItem, err := scValToNative(item)
+				if err != nil {
+					return nil, err
+				}
+				native = append(native, nativeItem)
+			}
+		}
+		return native, nil
+	case xdr.ScValTypeScvMap:
+		native := map[string]interface{}{}
+		if scMap, _ := value.GetMap(); scMap != nil {
+			for _, entry := range *scMap {
+				key, err := scValToNative(entry.Key)
+				if err != nil {
+					return nil, err
+				}
+				val, err := scValToNative(entry.Val)
+				if err != nil {
+					return nil, err
+				}
+				native[fmt.Sprint(key)] = val
+			}
+		}
+		return native, nil
+	}
+
+	return nil, fmt.Errorf("unsupported return value type %v", value.Type)
+}
@@ -0,0 +1,230 @@
+package dapp_develop
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-cmd/cmd"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/tools/stellar-hd-wallet/crypto/derivation"
+	"github.com/tyler-smith/go-bip39"
+
+	e2e "github.com/stellar/system-test"
+)
+
+// adds an identity to the cli config, the cli reads the secret from the tty rather than stdin,
+// so it is typed into a pseudo terminal once the prompt matching promptPattern is shown
+func addIdentityFromCliTool(identityName string, secretFlag string, promptPattern string, secret string, e2eConfig *e2e.E2EConfig) error {
+	envCmd := cmd.NewCmd("stellar",
+		"keys",
+		"add",
+		secretFlag,
+		identityName)
+
+	result, err := e2e.RunInteractiveCommand(envCmd, e2eConfig, []e2e.PromptResponse{
+		{Prompt: promptPattern, Response: secret},
+	})
+
+	if result.ExitCode != 0 || err != nil {
+		return e2e.RedactError(fmt.Errorf("stellar cli add identity %s had error %v, %v, output: %v", identityName, result.ExitCode, err, strings.Join(result.Stdout, "\n")))
+	}
+
+	return nil
+}
+
+// generates a new random identity in the cli config, without funding it
+func generateIdentityFromCliTool(identityName string, e2eConfig *e2e.E2EConfig) error {
+	envCmd := cmd.NewCmd("stellar",
+		"keys",
+		"generate",
+		identityName)
+
+	result, err := e2e.RunCommand(envCmd, e2eConfig)
+
+	if result.ExitCode != 0 || err != nil {
+		return fmt.Errorf("stellar cli generate identity %s had error %v, %v, stderr: %v", identityName, result.ExitCode, err, result.StderrTail())
+	}
+
+	return nil
+}
+
+// funds the identity's account from the friendbot of the network the rpc is on
+func fundIdentityFromCliTool(identityName string, e2eConfig *e2e.E2EConfig) error {
+	envCmd := cmd.NewCmd("stellar",
+		"keys",
+		"fund",
+		"--rpc-url", e2eConfig.TargetNetworkRPCURL,
+		"--network-passphrase", e2eConfig.TargetNetworkPassPhrase,
+		identityName)
+
+	result, err := e2e.RunCommand(envCmd, e2eConfig)
+
+	if result.ExitCode != 0 || err != nil {
+		return fmt.Errorf("stellar cli fund identity %s had error %v, %v, stderr: %v", identityName, result.ExitCode, err, result.StderrTail())
+	}
+
+	return nil
+}
+
+// returns the public key of the identity, hdIndex is optional and only applies to seed phrase identities
+func identityAddressFromCliTool(identityName string, hdIndex string, e2eConfig *e2e.E2EConfig) (string, error) {
+	args := []string{
+		"keys",
+		"address",
+		identityName,
+	}
+
+	if hdIndex != "" {
+		args = append(args, "--hd-path", hdIndex)
+	}
+
+	envCmd := cmd.NewCmd("stellar", args...)
+
+	result, err := e2e.RunCommand(envCmd, e2eConfig)
+
+	if result.ExitCode != 0 || err != nil {
+		return "", fmt.Errorf("stellar cli address of identity %s had error %v, %v, stderr: %v", identityName, result.ExitCode, err, result.StderrTail())
+	}
+
+	if len(result.Stdout) < 1 {
+		return "", fmt.Errorf("stellar cli address of identity %s returned no address, stderr: %v", identityName, result.StderrTail())
+	}
+
+	return strings.TrimSpace(result.Stdout[0]), nil
+}
+
+// returns the secret key of the identity, hdIndex is optional and only applies to seed phrase identities
+func identitySecretFromCliTool(identityName string, hdIndex string, e2eConfig *e2e.E2EConfig) (string, error) {
+	args := []string{
+		"keys",
+		"show",
+		identityName,
+	}
+
+	if hdIndex != "" {
+		args = append(args, "--hd-path", hdIndex)
+	}
+
+	envCmd := cmd.NewCmd("stellar", args...)
+
+	result, err := e2e.RunCommand(envCmd, e2eConfig)
+
+	if result.ExitCode != 0 || err != nil {
+		return "", fmt.Errorf("stellar cli show identity %s had error %v, %v, stderr: %v", identityName, result.ExitCode, err, result.StderrTail())
+	}
+
+	if len(result.Stdout) < 1 {
+		return "", fmt.Errorf("stellar cli show identity %s returned no secret key, stderr: %v", identityName, result.StderrTail())
+	}
+
+	return strings.TrimSpace(result.Stdout[0]), nil
+}
+
+// returns the names of all identities in the cli config
+func listIdentitiesFromCliTool(e2eConfig *e2e.E2EConfig) ([]string, error) {
+	envCmd := cmd.NewCmd("stellar",
+		"keys",
+		"ls")
+
+	result, err := e2e.RunCommand(envCmd, e2eConfig)
+
+	if result.ExitCode != 0 || err != nil {
+		return nil, fmt.Errorf("stellar cli list identities had error %v, %v, stderr: %v", result.ExitCode, err, result.StderrTail())
+	}
+
+	return parseListedNames(result.Stdout), nil
+}
+
+// removes the identity from the cli config
+func removeIdentityFromCliTool(identityName string, e2eConfig *e2e.E2EConfig) error {
+	envCmd := cmd.NewCmd("stellar",
+		"keys",
+		"rm",
+		identityName)
+
+	// cli versions that ask to confirm the removal read the answer from stdin
+	result, err := e2e.RunCommandWithStdin(envCmd, e2eConfig, strings.NewReader("y\n"))
+
+	if result.ExitCode != 0 || err != nil {
+		return fmt.Errorf("stellar cli remove identity %s had error %v, %v, stderr: %v", identityName, result.ExitCode, err, result.StderrTail())
+	}
+
+	return nil
+}
+
+// one name per line of 'keys ls' or 'network ls' output
+func parseListedNames(output []string) []string {
+	names := []string{}
+	for _, line := range output {
+		if name := strings.TrimSpace(line); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// the keypair derived with stellar/go from the secret key the cli shows for the identity
+func identityKeypairFromCliTool(identityName string, hdIndex string, e2eConfig *e2e.E2EConfig) (*keypair.Full, error) {
+	secret, err := identitySecretFromCliTool(identityName, hdIndex, e2eConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	kp, err := keypair.ParseFull(secret)
+	if err != nil {
+		return nil, e2e.RedactError(fmt.Errorf("stellar cli show identity %s did not return a valid secret key, %v", identityName, err))
+	}
+
+	return kp, nil
+}
+
+// checks the address the cli reports for the identity is the one derived with stellar/go
+// from the secret key the cli shows for it, returns that keypair
+func verifyIdentityKeys(identityName string, hdIndex string, e2eConfig *e2e.E2EConfig) (*keypair.Full, error) {
+	kp, err := identityKeypairFromCliTool(identityName, hdIndex, e2eConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := identityAddressFromCliTool(identityName, hdIndex, e2eConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if kp.Address() != address {
+		return nil, fmt.Errorf("stellar cli address of identity %s is %v, but its secret key is for %v", identityName, address, kp.Address())
+	}
+
+	return kp, nil
+}
+
+// the keypair at the hd index of a bip-39 seed phrase, derived as in SEP-0005 independently of the cli
+func deriveSeedPhraseKeypair(seedPhrase string, hdIndex uint32) (*keypair.Full, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(seedPhrase, "")
+	if err != nil {
+		return nil, fmt.Errorf("invalid seed phrase, %v", err)
+	}
+
+	key, err := derivation.DeriveForPath(fmt.Sprintf(derivation.StellarAccountPathFormat, hdIndex), seed)
+	if err != nil {
+		return nil, fmt.Errorf("not able to derive key at hd index %v, %v", hdIndex, err)
+	}
+
+	return keypair.FromRawSeed(key.RawSeed())
+}
+
+// a new random 12 word seed phrase, registered to be redacted from all output
+func newSeedPhrase() (string, error) {
+	entropy, err := bip39.NewEntropy(128)
+	if err != nil {
+		return "", fmt.Errorf("not able to generate seed phrase entropy, %v", err)
+	}
+
+	seedPhrase, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return "", fmt.Errorf("not able to generate seed phrase, %v", err)
+	}
+
+	e2e.RegisterSecret(seedPhrase)
+	return seedPhrase, nil
+}
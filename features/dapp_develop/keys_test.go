@@ -0,0 +1,31 @@
+package dapp_develop
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseListedNames(t *testing.T) {
+	assert.Equal(t, []string{"r1", "g1"}, parseListedNames([]string{"r1", "", "  g1  ", ""}))
+	assert.Empty(t, parseListedNames(nil))
+}
+
+// test vector 1 of SEP-0005
+func TestDeriveSeedPhraseKeypair(t *testing.T) {
+	seedPhrase := "illness spike retreat truth genius clock brain pass fit cave bargain toe"
+
+	kp, err := deriveSeedPhraseKeypair(seedPhrase, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "GDRXE2BQUC3AZNPVFSCEZ76NJ3WWL25FYFK6RGZGIEKWE4SOOHSUJUJ6", kp.Address())
+	assert.Equal(t, "SBGWSG6BTNCKCOB3DIFBGCVMUPQFYPA2G4O34RMTB343OYPXU5DJDVMN", kp.Seed())
+
+	kp, err = deriveSeedPhraseKeypair(seedPhrase, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "GBAW5XGWORWVFE2XTJYDTLDHXTY2Q2MO73HYCGB3XMFMQ562Q2W2GJQX", kp.Address())
+
+	_, err = deriveSeedPhraseKeypair("illness spike retreat truth genius clock brain pass fit cave bargain bargain", 0)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "illness")
+}
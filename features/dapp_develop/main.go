@@ -1,78 +1,52 @@
 package dapp_develop
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/go-cmd/cmd"
 
 	e2e "github.com/stellar/system-test"
 )
 
+// uses the clone and build of the example contract cached for this run, unless
+// ForceContractRebuild is set, the compiled wasm is placed in contractWorkingDirectory.
+// if ContractsSourcePath or ContractsWasmPath is configured, the contract comes from there instead of git.
 func compileContract(contractExamplesSubPath string, contractWorkingDirectory string, e2eConfig *e2e.E2EConfig) error {
-	envCmd := cmd.NewCmd("git", "clone", e2eConfig.SorobanExamplesRepoURL, contractWorkingDirectory)
-
-	status, _, err := e2e.RunCommand(envCmd, e2eConfig)
-
-	if status != 0 || err != nil {
-		return fmt.Errorf("git clone of soroban example contracts from %s had error %v, %v", e2eConfig.SorobanExamplesRepoURL, status, err)
-	}
-
-	envCmd = cmd.NewCmd("git", "checkout", e2eConfig.SorobanExamplesGitHash)
-	envCmd.Dir = contractWorkingDirectory
-
-	status, _, err = e2e.RunCommand(envCmd, e2eConfig)
-
-	if status != 0 || err != nil {
-		return fmt.Errorf("git checkout %v of sample contracts repo %s had error %v, %v", e2eConfig.SorobanExamplesGitHash, e2eConfig.SorobanExamplesRepoURL, status, err)
-	}
-
-	envCmd = cmd.NewCmd("stellar", "contract", "build")
-	envCmd.Dir = fmt.Sprintf("%s/%s", contractWorkingDirectory, contractExamplesSubPath)
-
-	status, _, err = e2e.RunCommand(envCmd, e2eConfig)
-
-	if status != 0 || err != nil {
-		return fmt.Errorf("cargo build of sample contract %v/%v had error %v, %v", e2eConfig.SorobanExamplesRepoURL, contractExamplesSubPath, status, err)
-	}
-
-	return nil
+	return examplesCache.compiledContract(contractExamplesSubPath, contractWorkingDirectory, e2eConfig)
 }
 
 // returns the deployed contract id
-func deployContract(compiledContractFileName string, contractWorkingDirectory string, contractExamplesSubPath string, installedContractId string, e2eConfig *e2e.E2EConfig) (string, error) {
-	var envCmd *cmd.Cmd
+func deployContract(compiledContractFileName string, contractWorkingDirectory string, contractExamplesSubPath string, installedContractId string, tool string, e2eConfig *e2e.E2EConfig) (string, error) {
+	var response string
+	var err error
 
 	if installedContractId != "" {
-		envCmd = cmd.NewCmd("stellar",
-			"contract",
-			"deploy",
-			"--quiet",
-			"--wasm-hash", installedContractId,
-			"--rpc-url", e2eConfig.TargetNetworkRPCURL,
-			"--source", e2eConfig.TargetNetworkSecretKey,
-			"--network-passphrase", e2eConfig.TargetNetworkPassPhrase)
-	} else {
-		envCmd = cmd.NewCmd("stellar",
-			"contract",
-			"deploy",
-			"--quiet",
-			"--wasm", fmt.Sprintf("./%s/%s/target/wasm32v1-none/release/%s", contractWorkingDirectory, contractExamplesSubPath, compiledContractFileName),
-			"--rpc-url", e2eConfig.TargetNetworkRPCURL,
-			"--source", e2eConfig.TargetNetworkSecretKey,
-			"--network-passphrase", e2eConfig.TargetNetworkPassPhrase)
+		// the installed code must still be the contract built locally before deploying by its hash
+		if err = verifyInstalledWasm(compiledWasmPath(compiledContractFileName, contractWorkingDirectory, contractExamplesSubPath), installedContractId, e2eConfig); err != nil {
+			return "", err
+		}
 	}
 
-	status, stdOut, err := e2e.RunCommand(envCmd, e2eConfig)
-
-	if status != 0 || err != nil {
-		return "", fmt.Errorf("stellar cli deployment of example contract %s had error %v, %v", compiledContractFileName, status, err)
+	switch tool {
+	case "CLI":
+		response, err = deployContractFromCliTool(compiledContractFileName, contractWorkingDirectory, contractExamplesSubPath, installedContractId, e2eConfig)
+	case "GO":
+		response, err = deployContractFromGoTool(compiledWasmPath(compiledContractFileName, contractWorkingDirectory, contractExamplesSubPath), installedContractId, e2eConfig)
+	default:
+		err = fmt.Errorf("%s tool not supported for deploy yet", tool)
 	}
 
-	if len(stdOut) < 1 {
-		return "", fmt.Errorf("stellar cli deployment of example contract %s returned no contract id", compiledContractFileName)
+	// tool errors can include command output and args, which may carry secrets
+	if err != nil {
+		return "", e2e.RedactError(err)
 	}
 
-	return stdOut[0], nil
+	return response, nil
 }
 
 func deployContractUsingConfigParams(compiledContractFileName string, contractWorkingDirectory string, contractExamplesSubPath string, identityName string, networkConfigName string, e2eConfig *e2e.E2EConfig) (string, error) {
@@ -80,21 +54,21 @@ func deployContractUsingConfigParams(compiledContractFileName string, contractWo
 		"contract",
 		"deploy",
 		"--quiet",
-		"--wasm", fmt.Sprintf("./%s/%s/target/wasm32v1-none/release/%s", contractWorkingDirectory, contractExamplesSubPath, compiledContractFileName),
+		"--wasm", compiledWasmPath(compiledContractFileName, contractWorkingDirectory, contractExamplesSubPath),
 		"--network", networkConfigName,
 		"--source", identityName)
 
-	status, stdOut, err := e2e.RunCommand(envCmd, e2eConfig)
+	result, err := e2e.RunCommand(envCmd, e2eConfig)
 
-	if status != 0 || err != nil {
-		return "", fmt.Errorf("stellar cli deployment of example contract %s had error %v, %v", compiledContractFileName, status, err)
+	if result.ExitCode != 0 || err != nil {
+		return "", fmt.Errorf("stellar cli deployment of example contract %s had error %v, %v, stderr: %v", compiledContractFileName, result.ExitCode, err, result.StderrTail())
 	}
 
-	if len(stdOut) < 1 {
-		return "", fmt.Errorf("stellar cli deployment of example contract %s returned no contract id", compiledContractFileName)
+	if len(result.Stdout) < 1 {
+		return "", fmt.Errorf("stellar cli deployment of example contract %s returned no contract id, stderr: %v", compiledContractFileName, result.StderrTail())
 	}
 
-	return stdOut[0], nil
+	return result.Stdout[0], nil
 }
 
 // returns the installed contract id
@@ -103,22 +77,55 @@ func installContract(compiledContractFileName string, contractWorkingDirectory s
 		"contract",
 		"install",
 		"--quiet",
-		"--wasm", fmt.Sprintf("./%s/%s/target/wasm32v1-none/release/%s", contractWorkingDirectory, contractExamplesSubPath, compiledContractFileName),
+		"--wasm", compiledWasmPath(compiledContractFileName, contractWorkingDirectory, contractExamplesSubPath),
 		"--rpc-url", e2eConfig.TargetNetworkRPCURL,
 		"--source", e2eConfig.TargetNetworkSecretKey,
 		"--network-passphrase", e2eConfig.TargetNetworkPassPhrase)
 
-	status, stdOut, err := e2e.RunCommand(envCmd, e2eConfig)
+	result, err := e2e.RunCommand(envCmd, e2eConfig)
+
+	if result.ExitCode != 0 || err != nil {
+		return "", fmt.Errorf("stellar cli install of example contract %s had error %v, %v, stderr: %v", compiledContractFileName, result.ExitCode, err, result.StderrTail())
+	}
+
+	if len(result.Stdout) < 1 {
+		return "", fmt.Errorf("stellar cli install of example contract %s returned no contract id, stderr: %v", compiledContractFileName, result.StderrTail())
+	}
+
+	if err = verifyInstalledWasm(compiledWasmPath(compiledContractFileName, contractWorkingDirectory, contractExamplesSubPath), result.Stdout[0], e2eConfig); err != nil {
+		return "", err
+	}
+
+	return result.Stdout[0], nil
+}
+
+func compiledWasmPath(compiledContractFileName string, contractWorkingDirectory string, contractExamplesSubPath string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", contractWorkingDirectory, contractExamplesSubPath, compiledContractTargetPath, compiledContractFileName)
+}
 
-	if status != 0 || err != nil {
-		return "", fmt.Errorf("stellar cli install of example contract %s had error %v, %v", compiledContractFileName, status, err)
+// checks the wasm hash reported by a tool is the sha256 of the locally compiled wasm,
+// and that the contract code stored on the network for that hash is byte identical to it
+func verifyInstalledWasm(wasmFilePath string, installedWasmHash string, e2eConfig *e2e.E2EConfig) error {
+	wasm, err := os.ReadFile(wasmFilePath)
+	if err != nil {
+		return fmt.Errorf("not able to read compiled wasm %s, %v", wasmFilePath, err)
+	}
+
+	localHash := sha256.Sum256(wasm)
+	if !strings.EqualFold(hex.EncodeToString(localHash[:]), strings.TrimSpace(installedWasmHash)) {
+		return fmt.Errorf("installed wasm hash %v does not match sha256 %x of compiled wasm %s", installedWasmHash, localHash, wasmFilePath)
+	}
+
+	onChainWasm, err := e2e.QueryContractCode(e2eConfig, hex.EncodeToString(localHash[:]))
+	if err != nil {
+		return fmt.Errorf("not able to get installed contract code for wasm hash %x, %v", localHash, err)
 	}
 
-	if len(stdOut) < 1 {
-		return "", fmt.Errorf("stellar cli install of example contract %s returned no contract id", compiledContractFileName)
+	if !bytes.Equal(wasm, onChainWasm) {
+		return fmt.Errorf("installed contract code for wasm hash %x is %v bytes and does not match the %v bytes of compiled wasm %s", localHash, len(onChainWasm), len(wasm), wasmFilePath)
 	}
 
-	return stdOut[0], nil
+	return nil
 }
 
 func createNetworkConfig(configName string, rpcUrl string, networkPassphrase string, e2eConfig *e2e.E2EConfig) error {
@@ -129,80 +136,78 @@ func createNetworkConfig(configName string, rpcUrl string, networkPassphrase str
 		"--network-passphrase", networkPassphrase,
 		configName)
 
-	status, _, err := e2e.RunCommand(envCmd, e2eConfig)
+	result, err := e2e.RunCommand(envCmd, e2eConfig)
 
-	if status != 0 || err != nil {
-		return fmt.Errorf("stellar cli create network config %s had error %v, %v", configName, status, err)
+	if result.ExitCode != 0 || err != nil {
+		return fmt.Errorf("stellar cli create network config %s had error %v, %v, stderr: %v", configName, result.ExitCode, err, result.StderrTail())
 	}
 
 	return nil
 }
 
-// uses 'expect' cli tool program, to forward the secret to the tty that cli wait for input
 func createIdentityConfig(identityName string, secretKey string, e2eConfig *e2e.E2EConfig) error {
-	envCmd := cmd.NewCmd("expect",
-		e2eConfig.FeaturePath+"/soroban_config.exp",
-		identityName,
-		secretKey)
-
-	status, _, err := e2e.RunCommand(envCmd, e2eConfig)
-
-	if status != 0 || err != nil {
-		return fmt.Errorf("stellar cli create identity config %s had error %v, %v", identityName, status, err)
-	}
+	return addIdentityFromCliTool(identityName, "--secret-key", `(?i)secret key`, secretKey, e2eConfig)
+}
 
-	return nil
+func createSeedPhraseIdentityConfig(identityName string, seedPhrase string, e2eConfig *e2e.E2EConfig) error {
+	return addIdentityFromCliTool(identityName, "--seed-phrase", `(?i)seed phrase`, seedPhrase, e2eConfig)
 }
 
-// returns the contract fn invocation response payload as a serialized string
+// returns the contract fn invocation response payload as a serialized string and the hash of the invocation tx,
+// the hash is empty if the tool did not report it
 // uses secret-key and network-passphrase directly on command
-func invokeContract(deployedContractId string, contractName string, functionName string, functionParams string, tool string, e2eConfig *e2e.E2EConfig) (string, error) {
-	var response string
+func invokeContract(deployedContractId string, contractName string, functionName string, functionParams string, tool string, e2eConfig *e2e.E2EConfig) (string, string, error) {
+	var response, txHash string
 	var err error
 
 	switch tool {
 	case "CLI":
-		response, err = invokeContractFromCliTool(deployedContractId, contractName, functionName, functionParams, e2eConfig)
+		response, txHash, err = invokeContractFromCliTool(deployedContractId, contractName, functionName, functionParams, e2eConfig)
 	case "NODEJS":
-		response, err = invokeContractFromNodeJSTool(deployedContractId, contractName, functionName, functionParams, e2eConfig)
+		response, txHash, err = invokeContractFromNodeJSTool(deployedContractId, contractName, functionName, functionParams, e2eConfig)
+	case "GO":
+		response, txHash, err = invokeContractFromGoTool(deployedContractId, contractName, functionName, functionParams, e2eConfig)
 	default:
 		err = fmt.Errorf("%s tool not supported for invoke yet", tool)
 	}
 
 	if err != nil {
-		return "", err
+		return "", "", e2e.RedactError(err)
 	}
 
-	return response, nil
+	return response, txHash, nil
 }
 
-// invokes the contract using identities and network from prior setup of config state in cli
-func invokeContractWithConfig(deployedContractId string, contractName string, functionName string, parameters string, tool string, identity string, networkConfig string, e2eConfig *e2e.E2EConfig) (string, error) {
-	var response string
+// invokes the contract using identities and network from prior setup of config state in cli, returns the same as invokeContract
+// identityHDIndex is optional, the hd index to sign with when identity is a seed phrase
+func invokeContractWithConfig(deployedContractId string, contractName string, functionName string, parameters string, tool string, identity string, identityHDIndex string, networkConfig string, e2eConfig *e2e.E2EConfig) (string, string, error) {
+	var response, txHash string
 	var err error
 
 	switch tool {
 	case "CLI":
-		response, err = invokeContractFromCliToolWithConfig(deployedContractId, contractName, functionName, parameters, identity, networkConfig, e2eConfig)
+		response, txHash, err = invokeContractFromCliToolWithConfig(deployedContractId, contractName, functionName, parameters, identity, identityHDIndex, networkConfig, e2eConfig)
 	case "NODEJS":
-		response, err = invokeContractFromNodeJSToolWithConfig(deployedContractId, contractName, functionName, parameters, identity, networkConfig, e2eConfig)
+		response, txHash, err = invokeContractFromNodeJSToolWithConfig(deployedContractId, contractName, functionName, parameters, identity, identityHDIndex, networkConfig, e2eConfig)
+	case "GO":
+		response, txHash, err = invokeContractFromGoToolWithConfig(deployedContractId, contractName, functionName, parameters, identity, identityHDIndex, networkConfig, e2eConfig)
 	default:
 		err = fmt.Errorf("%s tool not supported yet for invoker auth contract", tool)
 	}
 
 	if err != nil {
-		return "", err
+		return "", "", e2e.RedactError(err)
 	}
 
-	return response, nil
+	return response, txHash, nil
 }
 
-// returns all events as json array
+// returns all events, normalized from the output of the tool
 // ledgerFrom - required, starting point
 // deployedContractId - optional, the id of contract to filter events for or nil
 // tool - required, which tool to use to get events
-func getEvents(ledgerFrom uint32, deployedContractId string, tool string, size uint32, e2eConfig *e2e.E2EConfig) ([]map[string]interface{}, error) {
-	var response []map[string]interface{}
+func getEvents(ledgerFrom uint32, deployedContractId string, tool string, size uint32, e2eConfig *e2e.E2EConfig) ([]e2e.ContractEvent, error) {
+	var response []e2e.ContractEvent
 	var err error
 
 	switch tool {
@@ -210,12 +215,14 @@ func getEvents(ledgerFrom uint32, deployedContractId string, tool string, size u
 		response, err = getEventsFromCliTool(ledgerFrom, deployedContractId, size, e2eConfig)
 	case "NODEJS":
 		response, err = getEventsFromNodeJSTool(ledgerFrom, deployedContractId, size, e2eConfig)
+	case "GO":
+		response, err = getEventsFromGoTool(ledgerFrom, deployedContractId, size, e2eConfig)
 	default:
 		err = fmt.Errorf("%s tool not supported for events retrieval yet", tool)
 	}
 
 	if err != nil {
-		return nil, err
+		return nil, e2e.RedactError(err)
 	}
 
 	return response, nil
@@ -0,0 +1,128 @@
+package dapp_develop
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-cmd/cmd"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+
+	e2e "github.com/stellar/system-test"
+)
+
+// returns the names of all network configs the cli knows, including its built in defaults
+func listNetworkConfigsFromCliTool(e2eConfig *e2e.E2EConfig) ([]string, error) {
+	envCmd := cmd.NewCmd("stellar",
+		"network",
+		"ls")
+
+	result, err := e2e.RunCommand(envCmd, e2eConfig)
+
+	if result.ExitCode != 0 || err != nil {
+		return nil, fmt.Errorf("stellar cli list network configs had error %v, %v, stderr: %v", result.ExitCode, err, result.StderrTail())
+	}
+
+	return parseListedNames(result.Stdout), nil
+}
+
+func removeNetworkConfigFromCliTool(configName string, e2eConfig *e2e.E2EConfig) error {
+	envCmd := cmd.NewCmd("stellar",
+		"network",
+		"rm",
+		configName)
+
+	result, err := e2e.RunCommand(envCmd, e2eConfig)
+
+	if result.ExitCode != 0 || err != nil {
+		return fmt.Errorf("stellar cli remove network config %s had error %v, %v, stderr: %v", configName, result.ExitCode, err, result.StderrTail())
+	}
+
+	return nil
+}
+
+// runs a cli command that only depends on the network passphrase, with the given network flags and env vars,
+// returns the native asset contract id it computed, which identifies the passphrase of the network it resolved,
+// and the stderr of the command, with secrets redacted, which explains why it was rejected if it was
+func resolveNetworkFromCliTool(networkArgs []string, env e2e.CommandEnv, e2eConfig *e2e.E2EConfig) (string, string, error) {
+	args := append([]string{
+		"contract",
+		"id",
+		"asset",
+		"--asset", "native",
+	}, networkArgs...)
+
+	envCmd := cmd.NewCmd("stellar", args...)
+
+	result, err := e2e.RunCommandWithEnv(envCmd, e2eConfig, env)
+	stderr := result.StderrTail()
+
+	if result.ExitCode != 0 || err != nil {
+		return "", stderr, fmt.Errorf("stellar cli resolve network with %v had error %v, %v, stderr: %v", networkArgs, result.ExitCode, err, stderr)
+	}
+
+	if len(result.Stdout) < 1 {
+		return "", stderr, fmt.Errorf("stellar cli resolve network with %v returned no contract id, stderr: %v", networkArgs, stderr)
+	}
+
+	return strings.TrimSpace(result.Stdout[0]), stderr, nil
+}
+
+// the native asset contract id on the network with this passphrase, computed with stellar/go
+func nativeAssetContractId(networkPassphrase string) (string, error) {
+	contractId, err := xdr.MustNewNativeAsset().ContractID(networkPassphrase)
+	if err != nil {
+		return "", fmt.Errorf("not able to compute native asset contract id for network %q, %v", networkPassphrase, err)
+	}
+
+	return strkey.Encode(strkey.VersionByteContract, contractId[:])
+}
+
+// reads the file the cli saved a network config to, under its config home
+func readNetworkConfigFile(configName string, e2eConfig *e2e.E2EConfig) (map[string]string, error) {
+	configHome, ok := e2eConfig.CommandEnv["STELLAR_CONFIG_HOME"]
+	if !ok {
+		return nil, fmt.Errorf("no STELLAR_CONFIG_HOME is set for the cli, network config %s file location is unknown", configName)
+	}
+
+	path := filepath.Join(configHome, "network", configName+".toml")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("not able to read network config %s file, %v", configName, err)
+	}
+
+	values, err := parseNetworkConfigFile(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("network config %s file %s is not valid, %v", configName, path, err)
+	}
+
+	return values, nil
+}
+
+// the top level string values of a network config toml file, i.e. rpc_url and network_passphrase,
+// values of other types such as rpc_headers and any tables are skipped
+func parseNetworkConfigFile(content string) (map[string]string, error) {
+	values := map[string]string{}
+	for number, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			break
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %v is not a key value pair, %v", number+1, line)
+		}
+
+		if unquoted, err := strconv.Unquote(strings.TrimSpace(value)); err == nil {
+			values[strings.TrimSpace(key)] = unquoted
+		}
+	}
+	return values, nil
+}
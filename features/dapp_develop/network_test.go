@@ -0,0 +1,27 @@
+package dapp_develop
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNetworkConfigFile(t *testing.T) {
+	values, err := parseNetworkConfigFile("rpc_url = \"http://localhost:8000/rpc\"\nnetwork_passphrase = \"Standalone Network ; February 2017\"\nrpc_headers = []\n\n[extra]\nrpc_url = \"ignored\"\n")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"rpc_url":            "http://localhost:8000/rpc",
+		"network_passphrase": "Standalone Network ; February 2017",
+	}, values)
+
+	_, err = parseNetworkConfigFile("rpc_url\n")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 1")
+}
+
+func TestNativeAssetContractId(t *testing.T) {
+	contractId, err := nativeAssetContractId("Test SDF Network ; September 2015")
+	require.NoError(t, err)
+	assert.Equal(t, "CDLZFC3SYJYDZT7K67VZ75HPJVIEUVNIXF47ZG2FB2RMQQVU2HHGCYSC", contractId)
+}
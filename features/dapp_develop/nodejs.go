@@ -10,9 +10,9 @@ import (
 	e2e "github.com/stellar/system-test"
 )
 
-// return the fn response as a serialized string
+// return the fn response as a serialized string and the hash of the invocation tx
 // uses secret-key and network-passphrase directly on command
-func invokeContractFromNodeJSTool(deployedContractId, contractName, functionName string, functionParams string, e2eConfig *e2e.E2EConfig) (string, error) {
+func invokeContractFromNodeJSTool(deployedContractId, contractName, functionName string, functionParams string, e2eConfig *e2e.E2EConfig) (string, string, error) {
 	args := []string{
 		"--id", deployedContractId,
 		"--rpc-url", e2eConfig.TargetNetworkRPCURL,
@@ -24,26 +24,27 @@ func invokeContractFromNodeJSTool(deployedContractId, contractName, functionName
 		args = append(args, "--params", functionParams)
 	}
 	envCmd := cmd.NewCmd("./invoke.ts", args...)
-	status, stdOutLines, err := e2e.RunCommand(envCmd, e2eConfig)
-	stdOut := strings.TrimSpace(strings.Join(stdOutLines, "\n"))
+	result, err := e2e.RunCommand(envCmd, e2eConfig)
+	stdOut := strings.TrimSpace(strings.Join(result.Stdout, "\n"))
 
-	if status != 0 || err != nil {
-		return "", fmt.Errorf("nodejs invoke of example contract %s had error %v, %v, stdout: %v", contractName, status, err, stdOut)
+	if result.ExitCode != 0 || err != nil {
+		return "", "", fmt.Errorf("nodejs invoke of example contract %s had error %v, %v, stdout: %v, stderr: %v", contractName, result.ExitCode, err, stdOut, result.StderrTail())
 	}
 
 	if stdOut == "" {
-		return "", fmt.Errorf("nodejs invoke of example contract %s did not print any response", contractName)
+		return "", "", fmt.Errorf("nodejs invoke of example contract %s did not print any response, stderr: %v", contractName, result.StderrTail())
 	}
 
-	return stdOut, nil
+	// invoke.ts reports the tx hash on stderr the same as the cli
+	return stdOut, txHashFromOutput(result.Stderr), nil
 }
 
 // invokes the contract using identities and network from prior setup of config state in cli
-func invokeContractFromNodeJSToolWithConfig(deployedContractId, contractName, functionName, parameters, identity, networkConfig string, e2eConfig *e2e.E2EConfig) (string, error) {
-	return "", fmt.Errorf("invoke with named identity not supported for NODEJS tool")
+func invokeContractFromNodeJSToolWithConfig(deployedContractId, contractName, functionName, parameters, identity, identityHDIndex, networkConfig string, e2eConfig *e2e.E2EConfig) (string, string, error) {
+	return "", "", fmt.Errorf("invoke with named identity not supported for NODEJS tool")
 }
 
-func getEventsFromNodeJSTool(ledgerFrom uint32, deployedContractId string, size uint32, e2eConfig *e2e.E2EConfig) ([]map[string]interface{}, error) {
+func getEventsFromNodeJSTool(ledgerFrom uint32, deployedContractId string, size uint32, e2eConfig *e2e.E2EConfig) ([]e2e.ContractEvent, error) {
 	args := []string{
 		"--id", deployedContractId,
 		"--rpc-url", e2eConfig.TargetNetworkRPCURL,
@@ -52,23 +53,28 @@ func getEventsFromNodeJSTool(ledgerFrom uint32, deployedContractId string, size
 	}
 
 	envCmd := cmd.NewCmd("./events.ts", args...)
-	status, stdOutLines, err := e2e.RunCommand(envCmd, e2eConfig)
+	result, err := e2e.RunCommand(envCmd, e2eConfig)
 
-	var jsonEvents []map[string]interface{}
+	var jsonEvents []e2e.EventInfo
 
-	if status != 0 || err != nil {
-		return jsonEvents, fmt.Errorf("soroban js client get events had error %v, %v", status, err)
+	if result.ExitCode != 0 || err != nil {
+		return nil, fmt.Errorf("soroban js client get events had error %v, %v, stderr: %v", result.ExitCode, err, result.StderrTail())
 	}
 
-	stdOutEvents := strings.TrimSpace(strings.Join(stdOutLines, "\n"))
+	stdOutEvents := strings.TrimSpace(strings.Join(result.Stdout, "\n"))
 	if stdOutEvents == "" {
-		return jsonEvents, fmt.Errorf("soroban js client get events did not emit successful console response")
+		return nil, fmt.Errorf("soroban js client get events did not emit successful console response, stderr: %v", result.StderrTail())
 	}
 
 	err = json.Unmarshal([]byte(stdOutEvents), &jsonEvents)
 	if err != nil {
-		return jsonEvents, fmt.Errorf("soroban js client get events response output %v was not parseable as event json, %v", stdOutEvents, err)
+		return nil, fmt.Errorf("soroban js client get events response output %v was not parseable as event json, %v", stdOutEvents, err)
 	}
 
-	return jsonEvents, nil
+	contractEvents, err := e2e.ContractEventsFromEventInfos(jsonEvents)
+	if err != nil {
+		return nil, fmt.Errorf("soroban js client get events response output had invalid event, %v", err)
+	}
+
+	return contractEvents, nil
 }
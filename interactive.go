@@ -0,0 +1,291 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/go-cmd/cmd"
+)
+
+// how long to wait for a prompt when the exchange does not set its own timeout
+const DefaultPromptTimeout = 30 * time.Second
+
+// one step of an interactive command, wait for Prompt on the terminal then type Response
+type PromptResponse struct {
+	// regular expression matched against the terminal output since the previous prompt
+	Prompt string
+	// typed into the terminal followed by enter, once the prompt is seen
+	Response string
+	// how long to wait for the prompt, DefaultPromptTimeout if 0
+	Timeout time.Duration
+}
+
+// returned when the expected prompt is not seen on the terminal, either because it did not
+// appear before the timeout or the command exited first
+type PromptMismatchError struct {
+	CommandLine string
+	Prompt      string
+	// the terminal output after the previous prompt, which did not match
+	Output  string
+	Timeout time.Duration
+	Exited  bool
+}
+
+func (e *PromptMismatchError) Error() string {
+	reason := fmt.Sprintf("was not seen within %v", e.Timeout)
+	if e.Exited {
+		reason = "was not seen before the command exited"
+	}
+	return RedactSecrets(fmt.Sprintf("command %s prompt matching %q %s, terminal output since last prompt: %q", e.CommandLine, e.Prompt, reason, e.Output))
+}
+
+// InteractiveCommand runs a command attached to a pseudo terminal, for cli commands that
+// read input such as secrets from a tty rather than stdin. stdout and stderr are merged
+// on the terminal, so all output is reported as stdout.
+type InteractiveCommand struct {
+	config      *E2EConfig
+	commandLine string
+	env         []string
+	start       time.Time
+	cmd         *exec.Cmd
+	terminal    *os.File
+
+	mu      sync.Mutex
+	output  strings.Builder
+	matched int
+	// signalled, without blocking, whenever output is read
+	updated chan struct{}
+	// closed once the terminal reaches EOF, i.e. the command and its children have exited
+	readDone chan struct{}
+	// closed once the command has exited, waitErr is set before then
+	exited  chan struct{}
+	waitErr error
+}
+
+// starts the command on a new pseudo terminal, with the config's CommandEnv
+func StartInteractiveCommand(testCmd *cmd.Cmd, config *E2EConfig) (*InteractiveCommand, error) {
+	c := &InteractiveCommand{
+		config:      config,
+		commandLine: RedactSecrets(commandLine(testCmd)),
+		env:         commandEnvOverrides(testCmd.Env, config.CommandEnv),
+		updated:     make(chan struct{}, 1),
+		readDone:    make(chan struct{}),
+		exited:      make(chan struct{}),
+	}
+
+	if config.VerboseOutput {
+		fmt.Printf("running interactive command %s %v \n\n", testCmd.Name, redactArgs(testCmd.Args))
+	}
+
+	c.cmd = exec.Command(testCmd.Name, testCmd.Args...)
+	c.cmd.Dir = testCmd.Dir
+	c.cmd.Env = commandEnviron(testCmd.Env, config.CommandEnv)
+
+	c.start = time.Now()
+	terminal, err := pty.Start(c.cmd)
+	if err != nil {
+		return nil, fmt.Errorf("could not start command %s on a terminal, %v", c.commandLine, err)
+	}
+	c.terminal = terminal
+
+	go c.readTerminal()
+	go func() {
+		c.waitErr = c.cmd.Wait()
+		close(c.exited)
+	}()
+
+	return c, nil
+}
+
+func (c *InteractiveCommand) readTerminal() {
+	defer close(c.readDone)
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := c.terminal.Read(buf)
+		if n > 0 {
+			c.mu.Lock()
+			c.output.Write(buf[:n])
+			c.mu.Unlock()
+			if c.config.VerboseOutput {
+				fmt.Print(RedactSecrets(string(buf[:n])))
+			}
+			select {
+			case c.updated <- struct{}{}:
+			default:
+			}
+		}
+		// linux returns EIO rather than EOF once the terminal is closed by the command exiting
+		if err != nil {
+			return
+		}
+	}
+}
+
+// waits for output matching the prompt regular expression since the previous match,
+// a timeout of 0 uses DefaultPromptTimeout. returns *PromptMismatchError if it is not seen.
+func (c *InteractiveCommand) Expect(prompt string, timeout time.Duration) error {
+	pattern, err := regexp.Compile(prompt)
+	if err != nil {
+		return fmt.Errorf("invalid prompt pattern %q, %v", prompt, err)
+	}
+	if timeout <= 0 {
+		timeout = DefaultPromptTimeout
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		if c.match(pattern) {
+			return nil
+		}
+
+		select {
+		case <-c.updated:
+		case <-c.readDone:
+			// all output has been read, so this is the last chance to match
+			if c.match(pattern) {
+				return nil
+			}
+			return c.mismatch(prompt, timeout, true)
+		case <-timer.C:
+			return c.mismatch(prompt, timeout, false)
+		}
+	}
+}
+
+func (c *InteractiveCommand) match(pattern *regexp.Regexp) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	loc := pattern.FindStringIndex(c.output.String()[c.matched:])
+	if loc == nil {
+		return false
+	}
+	c.matched += loc[1]
+	return true
+}
+
+func (c *InteractiveCommand) mismatch(prompt string, timeout time.Duration, exited bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return &PromptMismatchError{
+		CommandLine: c.commandLine,
+		Prompt:      prompt,
+		Output:      c.output.String()[c.matched:],
+		Timeout:     timeout,
+		Exited:      exited,
+	}
+}
+
+// types the response into the terminal followed by enter
+func (c *InteractiveCommand) Send(response string) error {
+	if _, err := c.terminal.Write([]byte(response + "\r")); err != nil {
+		return fmt.Errorf("could not send response to command %s, %v", c.commandLine, err)
+	}
+	return nil
+}
+
+// waits for the command to exit, killing it if it has not within timeout, a timeout of 0 means no timeout.
+// the command is recorded in the config's transcript. returns *CommandTimeoutError if it was killed.
+// the returned result is never nil, so output captured up to a failure can be reported.
+func (c *InteractiveCommand) Wait(timeout time.Duration) (*CommandResult, error) {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	var err error
+	select {
+	case <-c.exited:
+		err = c.exitError()
+	case <-expired:
+		c.kill()
+		err = context.DeadlineExceeded
+	}
+
+	result := c.result()
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = &CommandTimeoutError{Result: result, Timeout: timeout, Err: err}
+	}
+
+	c.config.Transcript.RecordCommand(c.start, result, c.env, err)
+	return result, err
+}
+
+// kills the command's process group, pty.Start runs it in its own session, then waits for it to exit
+func (c *InteractiveCommand) kill() {
+	_ = killProcessGroup(c.cmd.Process.Pid)
+	<-c.exited
+}
+
+// a non zero exit is reported through the result's ExitCode rather than as an error, as with RunCommand
+func (c *InteractiveCommand) exitError() error {
+	var exitErr *exec.ExitError
+	if errors.As(c.waitErr, &exitErr) {
+		return nil
+	}
+	return c.waitErr
+}
+
+func (c *InteractiveCommand) result() *CommandResult {
+	// background children may hold the terminal open, so output is only waited on briefly
+	select {
+	case <-c.readDone:
+	case <-time.After(time.Second):
+	}
+	_ = c.terminal.Close()
+
+	c.mu.Lock()
+	output := c.output.String()
+	c.mu.Unlock()
+
+	output = strings.TrimRight(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	stdout := []string{}
+	if output != "" {
+		stdout = strings.Split(output, "\n")
+	}
+
+	return &CommandResult{
+		CommandLine: c.commandLine,
+		ExitCode:    c.cmd.ProcessState.ExitCode(),
+		Stdout:      stdout,
+		Stderr:      []string{},
+		Duration:    time.Since(c.start),
+	}
+}
+
+// runs the command on a pseudo terminal, answering each prompt in order, then waits for it to exit
+// within the config's CommandTimeout. the command is killed if a prompt is not seen.
+func RunInteractiveCommand(testCmd *cmd.Cmd, config *E2EConfig, exchanges []PromptResponse) (*CommandResult, error) {
+	c, err := StartInteractiveCommand(testCmd, config)
+	if err != nil {
+		return &CommandResult{CommandLine: RedactSecrets(commandLine(testCmd)), ExitCode: -1, Stdout: []string{}, Stderr: []string{}}, err
+	}
+
+	for _, exchange := range exchanges {
+		if err = c.Expect(exchange.Prompt, exchange.Timeout); err == nil {
+			err = c.Send(exchange.Response)
+		}
+		if err != nil {
+			c.kill()
+			result := c.result()
+			config.Transcript.RecordCommand(c.start, result, c.env, err)
+			return result, err
+		}
+	}
+
+	return c.Wait(config.CommandTimeout)
+}
@@ -0,0 +1,71 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-cmd/cmd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const promptScript = `printf "Type a secret key: "; stty -echo; read key; stty echo; echo; echo "added $key"`
+
+func TestRunInteractiveCommand(t *testing.T) {
+	result, err := RunInteractiveCommand(cmd.NewCmd("sh", "-c", promptScript), &E2EConfig{CommandTimeout: time.Minute}, []PromptResponse{
+		{Prompt: `(?i)secret key`, Response: "alice"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.ExitCode)
+	assert.Contains(t, result.Stdout, "added alice")
+}
+
+func TestRunInteractiveCommandExitCode(t *testing.T) {
+	result, err := RunInteractiveCommand(cmd.NewCmd("sh", "-c", "echo failed; exit 3"), &E2EConfig{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.ExitCode)
+	assert.Equal(t, []string{"failed"}, result.Stdout)
+}
+
+func TestRunInteractiveCommandPromptTimeout(t *testing.T) {
+	start := time.Now()
+	result, err := RunInteractiveCommand(cmd.NewCmd("sh", "-c", promptScript), &E2EConfig{}, []PromptResponse{
+		{Prompt: `seed phrase`, Response: "alice", Timeout: 200 * time.Millisecond},
+	})
+	assert.Less(t, time.Since(start), 10*time.Second)
+
+	var mismatch *PromptMismatchError
+	require.True(t, errors.As(err, &mismatch), "got %v", err)
+	assert.False(t, mismatch.Exited)
+	assert.Equal(t, "Type a secret key: ", mismatch.Output)
+	assert.Contains(t, err.Error(), `prompt matching "seed phrase" was not seen within 200ms`)
+	assert.Contains(t, err.Error(), "Type a secret key: ")
+	assert.NotEqual(t, 0, result.ExitCode)
+}
+
+func TestRunInteractiveCommandExitsBeforePrompt(t *testing.T) {
+	_, err := RunInteractiveCommand(cmd.NewCmd("sh", "-c", "echo no prompt here"), &E2EConfig{}, []PromptResponse{
+		{Prompt: `secret key`, Response: "alice"},
+	})
+
+	var mismatch *PromptMismatchError
+	require.True(t, errors.As(err, &mismatch), "got %v", err)
+	assert.True(t, mismatch.Exited)
+	assert.Contains(t, err.Error(), "was not seen before the command exited")
+	assert.Contains(t, mismatch.Output, "no prompt here")
+}
+
+func TestInteractiveCommandWaitTimeout(t *testing.T) {
+	c, err := StartInteractiveCommand(cmd.NewCmd("sh", "-c", "sleep 60"), &E2EConfig{})
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = c.Wait(200 * time.Millisecond)
+	assert.Less(t, time.Since(start), 10*time.Second)
+
+	var timeoutErr *CommandTimeoutError
+	require.True(t, errors.As(err, &timeoutErr), "got %v", err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
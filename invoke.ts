@@ -55,8 +55,12 @@ async function main() {
     }
     // @ts-ignore client[functionName] is defined dynamically
     const tx = await client[functionName](args);
-    const { result } = await tx.signAndSend({ force: true });
-    console.log(JSON.stringify(result));
+    const sent = await tx.signAndSend({ force: true });
+    // reported on stderr the same as the cli, so the test can fetch the tx
+    if (sent.sendTransactionResponse) {
+      console.error(`Transaction hash is ${sent.sendTransactionResponse.hash}`);
+    }
+    console.log(JSON.stringify(sent.result));
     return;
   } else {
     const server = new rpc.Server(rpcUrl, { allowHttp: true });
@@ -80,6 +84,7 @@ async function main() {
     if (send.errorResult) {
       throw new Error(`Transaction failed: ${JSON.stringify(send)}`);
     }
+    console.error(`Transaction hash is ${send.hash}`);
     let response = await server.getTransaction(send.hash);
     for (let i = 0; i < 50; i++) {
       switch (response.status) {
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package e2e
+
+import "syscall"
+
+// kills every process in the process group led by pid, commands are started in their own group
+func killProcessGroup(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGKILL)
+}
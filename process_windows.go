@@ -0,0 +1,14 @@
+//go:build windows
+
+package e2e
+
+import "os"
+
+// windows has no process groups, only the command's own process is killed
+func killProcessGroup(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Kill()
+}
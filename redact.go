@@ -0,0 +1,138 @@
+package e2e
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/stellar/go/strkey"
+)
+
+// a stellar secret seed strkey, candidates are checked with strkey before being redacted
+var secretSeedPattern = regexp.MustCompile(`S[A-Z2-7]{55}`)
+
+const RedactedSecret = "<redacted secret>"
+
+// values shorter than this are not registered, to avoid masking common words
+const minRegisteredSecretLength = 8
+
+var registeredSecrets = struct {
+	sync.RWMutex
+	values []string
+}{}
+
+// registers a value, i.e. a secret key or seed phrase, to be redacted from all output.
+// any valid secret seed strkey is redacted without needing to be registered.
+func RegisterSecret(secret string) {
+	secret = strings.TrimSpace(secret)
+	if len(secret) < minRegisteredSecretLength {
+		return
+	}
+
+	registeredSecrets.Lock()
+	defer registeredSecrets.Unlock()
+
+	for _, value := range registeredSecrets.values {
+		if value == secret {
+			return
+		}
+	}
+	registeredSecrets.values = append(registeredSecrets.values, secret)
+	// longest first, so a secret containing another is fully redacted
+	sort.Slice(registeredSecrets.values, func(i, j int) bool {
+		return len(registeredSecrets.values[i]) > len(registeredSecrets.values[j])
+	})
+}
+
+// replaces every registered secret and every valid secret seed strkey in value
+func RedactSecrets(value string) string {
+	registeredSecrets.RLock()
+	for _, secret := range registeredSecrets.values {
+		value = strings.ReplaceAll(value, secret, RedactedSecret)
+	}
+	registeredSecrets.RUnlock()
+
+	return secretSeedPattern.ReplaceAllStringFunc(value, func(candidate string) string {
+		if strkey.IsValidEd25519SecretSeed(candidate) {
+			return RedactedSecret
+		}
+		return candidate
+	})
+}
+
+func redactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		redacted[i] = RedactSecrets(arg)
+	}
+	return redacted
+}
+
+type redactedError struct {
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return RedactSecrets(e.err.Error())
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// wraps err so its message has secrets redacted, errors.Is and errors.As still see the original
+func RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, redacted := err.(*redactedError); redacted {
+		return err
+	}
+	return &redactedError{err: err}
+}
+
+// RedactingWriter redacts secrets from everything written through it. Output is
+// buffered per line, so a secret split across writes is still redacted, call Flush
+// to write out a trailing partial line.
+type RedactingWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	buf []byte
+}
+
+func NewRedactingWriter(w io.Writer) *RedactingWriter {
+	return &RedactingWriter{w: w}
+}
+
+func (w *RedactingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	end := bytes.LastIndexByte(w.buf, '\n')
+	if end < 0 {
+		return len(p), nil
+	}
+
+	lines := string(w.buf[:end+1])
+	w.buf = append(w.buf[:0], w.buf[end+1:]...)
+	if _, err := io.WriteString(w.w, RedactSecrets(lines)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *RedactingWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := io.WriteString(w.w, RedactSecrets(string(w.buf)))
+	w.buf = w.buf[:0]
+	return err
+}
@@ -0,0 +1,83 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactSecrets(t *testing.T) {
+	secret := keypair.MustRandom()
+	assert.Equal(t, "--source <redacted secret> --id "+secret.Address(), RedactSecrets("--source "+secret.Seed()+" --id "+secret.Address()))
+
+	// looks like a secret seed but is not a valid strkey
+	notSecret := "S" + strings.Repeat("A", 55)
+	assert.Equal(t, notSecret, RedactSecrets(notSecret))
+}
+
+// restores the registered secrets to what they were before the test, once it completes
+func restoreRegisteredSecrets(t *testing.T) {
+	registeredSecrets.RLock()
+	values := append([]string{}, registeredSecrets.values...)
+	registeredSecrets.RUnlock()
+
+	t.Cleanup(func() {
+		registeredSecrets.Lock()
+		registeredSecrets.values = values
+		registeredSecrets.Unlock()
+	})
+}
+
+func TestRegisterSecret(t *testing.T) {
+	restoreRegisteredSecrets(t)
+	RegisterSecret("correct horse battery staple")
+	RegisterSecret("correct horse battery staple with more words")
+	RegisterSecret("short")
+
+	assert.Equal(t, "phrase <redacted secret> end", RedactSecrets("phrase correct horse battery staple with more words end"))
+	assert.Equal(t, "phrase <redacted secret>", RedactSecrets("phrase correct horse battery staple"))
+	assert.Equal(t, "short", RedactSecrets("short"))
+}
+
+func TestRegisterSecretRestored(t *testing.T) {
+	t.Run("registers", func(t *testing.T) {
+		restoreRegisteredSecrets(t)
+		RegisterSecret("tr0ub4dor and 3")
+		assert.Equal(t, RedactedSecret, RedactSecrets("tr0ub4dor and 3"))
+	})
+
+	assert.Equal(t, "tr0ub4dor and 3", RedactSecrets("tr0ub4dor and 3"))
+}
+
+func TestRedactError(t *testing.T) {
+	secret := keypair.MustRandom()
+	err := RedactError(fmt.Errorf("invoke with %s had error, %w", secret.Seed(), context.DeadlineExceeded))
+
+	assert.Equal(t, "invoke with <redacted secret> had error, context deadline exceeded", err.Error())
+	assert.Equal(t, "invoke with <redacted secret> had error, context deadline exceeded", fmt.Sprintf("%+v", err))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Same(t, err, RedactError(err))
+	assert.NoError(t, RedactError(nil))
+}
+
+func TestRedactingWriter(t *testing.T) {
+	secret := keypair.MustRandom()
+	var out bytes.Buffer
+	w := NewRedactingWriter(&out)
+
+	// the secret is split across writes
+	_, err := w.Write([]byte("--source " + secret.Seed()[:20]))
+	require.NoError(t, err)
+	_, err = w.Write([]byte(secret.Seed()[20:] + "\nnext " + secret.Seed()))
+	require.NoError(t, err)
+	assert.Equal(t, "--source <redacted secret>\n", out.String())
+
+	require.NoError(t, w.Flush())
+	assert.Equal(t, "--source <redacted secret>\nnext <redacted secret>", out.String())
+}
@@ -0,0 +1,196 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// default per call timeout applied when the caller's context has no deadline
+const DefaultRPCTimeout = 30 * time.Second
+
+// shared across all clients so request ids stay unique within a test run
+var rpcRequestID uint64
+
+type rpcRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      uint64      `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      uint64          `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	if e.Data != "" {
+		return fmt.Sprintf("rpc error code %d, %s, %s", e.Code, e.Message, e.Data)
+	}
+	return fmt.Sprintf("rpc error code %d, %s", e.Code, e.Message)
+}
+
+// RPCClient is a minimal JSON-RPC 2.0 client for the target network rpc server.
+// Each rpc method is exposed as a typed method which delegates to Call.
+type RPCClient struct {
+	URL        string
+	HTTPClient *http.Client
+	// applied to each call unless the caller's context already has a deadline
+	Timeout       time.Duration
+	VerboseOutput bool
+	// if set, every request and response is recorded to it
+	Transcript *Transcript
+}
+
+func NewRPCClient(e2eConfig *E2EConfig) *RPCClient {
+	return &RPCClient{
+		URL:           e2eConfig.TargetNetworkRPCURL,
+		HTTPClient:    http.DefaultClient,
+		Timeout:       DefaultRPCTimeout,
+		VerboseOutput: e2eConfig.VerboseOutput,
+		Transcript:    e2eConfig.Transcript,
+	}
+}
+
+// Call sends one json-rpc request and decodes the response result into result.
+// result may be nil if the caller does not need the response payload.
+// An error response from the server is returned as *RPCError.
+func (c *RPCClient) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline && c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	request := rpcRequest{
+		JSONRPC: "2.0",
+		ID:      atomic.AddUint64(&rpcRequestID, 1),
+		Method:  method,
+		Params:  params,
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("soroban rpc %s, not able to serialize request, %w", method, err)
+	}
+
+	if c.VerboseOutput {
+		fmt.Printf("rpc request %s\n\n", RedactSecrets(string(body)))
+	}
+
+	start := time.Now()
+	respBody, statusCode, err := c.send(ctx, method, body)
+	c.Transcript.RecordRPC(start, method, body, respBody, err)
+	if err != nil {
+		return err
+	}
+
+	if c.VerboseOutput {
+		fmt.Printf("rpc response %s\n\n", RedactSecrets(string(respBody)))
+	}
+
+	var rpcResp rpcResponse
+	if err = json.Unmarshal(respBody, &rpcResp); err != nil {
+		return fmt.Errorf("soroban rpc %s, not able to parse response, http status %v, %s, %w", method, statusCode, respBody, err)
+	}
+
+	if rpcResp.Error != nil {
+		return fmt.Errorf("soroban rpc %s, got error response, %w", method, rpcResp.Error)
+	}
+
+	if rpcResp.ID != request.ID {
+		return fmt.Errorf("soroban rpc %s, response id %v does not match request id %v", method, rpcResp.ID, request.ID)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	if err = json.Unmarshal(rpcResp.Result, result); err != nil {
+		return fmt.Errorf("soroban rpc %s, not able to parse result, %s, %w", method, rpcResp.Result, err)
+	}
+
+	return nil
+}
+
+// posts the serialized request, returns the response body and http status code
+func (c *RPCClient) send(ctx context.Context, method string, body []byte) ([]byte, int, error) {
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, fmt.Errorf("soroban rpc %s, not able to create request, %w", method, err)
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(httpRequest)
+	if err != nil {
+		return nil, 0, fmt.Errorf("soroban rpc %s had error, %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("soroban rpc %s, not able to read response, %w", method, err)
+	}
+
+	return respBody, resp.StatusCode, nil
+}
+
+func (c *RPCClient) GetLatestLedger(ctx context.Context) (LatestLedgerResult, error) {
+	var result LatestLedgerResult
+	err := c.Call(ctx, "getLatestLedger", nil, &result)
+	return result, err
+}
+
+// keys are base64 encoded xdr LedgerKey
+func (c *RPCClient) GetLedgerEntries(ctx context.Context, keys []string) (LedgerEntriesResult, error) {
+	var result LedgerEntriesResult
+	err := c.Call(ctx, "getLedgerEntries", map[string]interface{}{"keys": keys}, &result)
+	return result, err
+}
+
+func (c *RPCClient) GetTransaction(ctx context.Context, txHashId string) (*TransactionStatusResponse, error) {
+	var result TransactionStatusResponse
+	if err := c.Call(ctx, "getTransaction", map[string]interface{}{"hash": txHashId}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// txEnvelope is the base64 encoded xdr TransactionEnvelope
+func (c *RPCClient) SendTransaction(ctx context.Context, txEnvelope string) (*TransactionResponse, error) {
+	var result TransactionResponse
+	if err := c.Call(ctx, "sendTransaction", map[string]interface{}{"transaction": txEnvelope}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// txEnvelope is the base64 encoded xdr TransactionEnvelope
+func (c *RPCClient) SimulateTransaction(ctx context.Context, txEnvelope string) (*SimulateTransactionResponse, error) {
+	var result SimulateTransactionResponse
+	if err := c.Call(ctx, "simulateTransaction", map[string]interface{}{"transaction": txEnvelope}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *RPCClient) GetEvents(ctx context.Context, request GetEventsRequest) (*GetEventsResponse, error) {
+	var result GetEventsResponse
+	if err := c.Call(ctx, "getEvents", request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,74 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRPCServer(t *testing.T, handler func(request rpcRequest) interface{}) *RPCClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request rpcRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, "2.0", request.JSONRPC)
+		response := map[string]interface{}{"jsonrpc": "2.0", "id": request.ID}
+		switch payload := handler(request).(type) {
+		case *RPCError:
+			response["error"] = payload
+		default:
+			response["result"] = payload
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(response))
+	}))
+	t.Cleanup(server.Close)
+
+	return NewRPCClient(&E2EConfig{TargetNetworkRPCURL: server.URL})
+}
+
+func TestRPCClientCall(t *testing.T) {
+	var requestIds []uint64
+	client := newTestRPCServer(t, func(request rpcRequest) interface{} {
+		requestIds = append(requestIds, request.ID)
+		assert.Equal(t, "getLatestLedger", request.Method)
+		return LatestLedgerResult{Hash: "abc", ProtocolVersion: 22, Sequence: 100}
+	})
+
+	latestLedger, err := client.GetLatestLedger(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, LatestLedgerResult{Hash: "abc", ProtocolVersion: 22, Sequence: 100}, latestLedger)
+
+	_, err = client.GetLatestLedger(context.Background())
+	require.NoError(t, err)
+	require.Len(t, requestIds, 2)
+	assert.NotEqual(t, requestIds[0], requestIds[1])
+}
+
+func TestRPCClientCallErrorResponse(t *testing.T) {
+	client := newTestRPCServer(t, func(request rpcRequest) interface{} {
+		return &RPCError{Code: -32602, Message: "invalid params", Data: "bad hash"}
+	})
+
+	_, err := client.GetTransaction(context.Background(), "xyz")
+	var rpcErr *RPCError
+	require.True(t, errors.As(err, &rpcErr))
+	assert.Equal(t, int64(-32602), rpcErr.Code)
+	assert.Equal(t, "bad hash", rpcErr.Data)
+}
+
+func TestRPCClientCallTimeout(t *testing.T) {
+	client := newTestRPCServer(t, func(request rpcRequest) interface{} {
+		time.Sleep(200 * time.Millisecond)
+		return LatestLedgerResult{}
+	})
+	client.Timeout = 10 * time.Millisecond
+
+	_, err := client.GetLatestLedger(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
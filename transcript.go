@@ -0,0 +1,129 @@
+package e2e
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Transcript records every command and rpc call made during a scenario to a file,
+// so a failed run can be inspected afterwards without relying on verbose console output.
+// secret keys are redacted from everything written. A nil *Transcript records nothing.
+type Transcript struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// creates the transcript file at path, creating parent directories as needed
+func NewTranscript(path string) (*Transcript, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("could not create transcript directory for %s, %v", path, err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not create transcript %s, %v", path, err)
+	}
+
+	return &Transcript{path: path, file: file}, nil
+}
+
+func (t *Transcript) Path() string {
+	if t == nil {
+		return ""
+	}
+	return t.path
+}
+
+func (t *Transcript) Close() error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.file.Close()
+}
+
+// records a finished command with the env vars it was given on top of the inherited environment
+func (t *Transcript) RecordCommand(start time.Time, result *CommandResult, env []string, err error) {
+	if t == nil {
+		return
+	}
+
+	var entry strings.Builder
+	fmt.Fprintf(&entry, "=== command %s (%v)\n", start.Format(time.RFC3339Nano), result.Duration)
+	fmt.Fprintf(&entry, "command: %s\n", result.CommandLine)
+	for _, envVar := range env {
+		fmt.Fprintf(&entry, "env: %s\n", envVar)
+	}
+	fmt.Fprintf(&entry, "exit code: %v\n", result.ExitCode)
+	if err != nil {
+		fmt.Fprintf(&entry, "error: %v\n", err)
+	}
+	writeTranscriptSection(&entry, "stdout", strings.Join(result.Stdout, "\n"))
+	writeTranscriptSection(&entry, "stderr", strings.Join(result.Stderr, "\n"))
+
+	t.write(entry.String())
+}
+
+// records a json-rpc call, response is empty if none was received
+func (t *Transcript) RecordRPC(start time.Time, method string, request []byte, response []byte, err error) {
+	if t == nil {
+		return
+	}
+
+	var entry strings.Builder
+	fmt.Fprintf(&entry, "=== rpc %s %s (%v)\n", method, start.Format(time.RFC3339Nano), time.Since(start))
+	if err != nil {
+		fmt.Fprintf(&entry, "error: %v\n", err)
+	}
+	writeTranscriptSection(&entry, "request", string(request))
+	writeTranscriptSection(&entry, "response", string(response))
+
+	t.write(entry.String())
+}
+
+func (t *Transcript) write(entry string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// a transcript failing to write should not fail the scenario it is recording
+	_, _ = io.WriteString(t.file, RedactSecrets(entry)+"\n")
+}
+
+func writeTranscriptSection(entry *strings.Builder, name string, content string) {
+	fmt.Fprintf(entry, "--- %s\n", name)
+	if content != "" {
+		entry.WriteString(strings.TrimRight(content, "\n"))
+		entry.WriteString("\n")
+	}
+}
+
+// env overrides as sorted KEY=value entries, later envs override earlier ones for the same key
+func commandEnvOverrides(cmdEnv []string, envs ...CommandEnv) []string {
+	merged := map[string]string{}
+	for _, envVar := range cmdEnv {
+		if key, value, found := strings.Cut(envVar, "="); found {
+			merged[key] = value
+		}
+	}
+	for _, env := range envs {
+		for key, value := range env {
+			merged[key] = value
+		}
+	}
+
+	overrides := make([]string, 0, len(merged))
+	for key, value := range merged {
+		overrides = append(overrides, key+"="+value)
+	}
+	sort.Strings(overrides)
+	return overrides
+}
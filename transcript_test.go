@@ -0,0 +1,52 @@
+package e2e
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-cmd/cmd"
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranscriptRecordsCommandsAndRPC(t *testing.T) {
+	secret := keypair.MustRandom()
+	transcript, err := NewTranscript(filepath.Join(t.TempDir(), "scenario", "transcript.log"))
+	require.NoError(t, err)
+
+	config := &E2EConfig{Transcript: transcript, CommandEnv: CommandEnv{"STELLAR_CONFIG_HOME": "/tmp/config"}}
+	_, err = RunCommandWithEnv(cmd.NewCmd("sh", "-c", "echo out; echo err >&2; exit 2", secret.Seed()), config, CommandEnv{"RUST_LOG": "debug"})
+	require.NoError(t, err)
+
+	client := newTestRPCServer(t, func(request rpcRequest) interface{} {
+		return LatestLedgerResult{Hash: "abc", Sequence: 10}
+	})
+	client.Transcript = transcript
+	_, err = client.GetLatestLedger(context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, transcript.Close())
+	content, err := os.ReadFile(transcript.Path())
+	require.NoError(t, err)
+
+	assert.Contains(t, string(content), "=== command ")
+	assert.Contains(t, string(content), "command: sh -c \"echo out; echo err >&2; exit 2\" <redacted secret>")
+	assert.Contains(t, string(content), "env: RUST_LOG=debug\nenv: STELLAR_CONFIG_HOME=/tmp/config\n")
+	assert.Contains(t, string(content), "exit code: 2\n")
+	assert.Contains(t, string(content), "--- stdout\nout\n--- stderr\nerr\n")
+	assert.Contains(t, string(content), "=== rpc getLatestLedger ")
+	assert.Contains(t, string(content), `"method":"getLatestLedger"`)
+	assert.Contains(t, string(content), `"sequence":10`)
+	assert.NotContains(t, string(content), secret.Seed())
+}
+
+func TestNilTranscriptRecordsNothing(t *testing.T) {
+	var transcript *Transcript
+	transcript.RecordCommand(time.Now(), &CommandResult{}, nil, nil)
+	assert.NoError(t, transcript.Close())
+	assert.Equal(t, "", transcript.Path())
+}